@@ -0,0 +1,79 @@
+package main
+
+import (
+	"github.com/dt-rush/go-sdl2-pathfinding/pathfinding"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// colors given to agents in turn, by id
+var agentColors = []sdl.Color{
+	{R: 255, G: 128, B: 0},
+	{R: 255, G: 0, B: 255},
+	{R: 255, G: 255, B: 0},
+	{R: 128, G: 255, B: 128},
+	{R: 128, G: 128, B: 255},
+	{R: 255, G: 128, B: 128},
+}
+
+// a unit walking the grid along its own path to its own goal
+type Agent struct {
+	id int
+	// world-space position
+	pos  pathfinding.Vec2D
+	goal *pathfinding.Position
+	// the cells still to walk through, the next first
+	path []pathfinding.Position
+	// world units per second
+	speed  float64
+	color  sdl.Color
+	ticket *pathfinding.PathTicket
+}
+
+// make an agent standing at the center of a cell
+func NewAgent(g *Grid, id int, cell pathfinding.Position) *Agent {
+	return &Agent{
+		id:    id,
+		pos:   g.GridCellSpaceToGridWorldSpace(cell),
+		speed: AGENT_SPEED * g.CellWorldSize(),
+		color: agentColors[id%len(agentColors)],
+	}
+}
+
+// the cell the agent is in
+func (a *Agent) Cell(g *Grid) pathfinding.Position {
+	return g.GridWorldSpaceToGridCellSpace(a.pos)
+}
+
+// walk along the path for dt seconds. Returns true if the agent moved
+func (a *Agent) Update(g *Grid, dt float64) bool {
+	step := a.speed * dt
+	moved := false
+	for step > 0 && len(a.path) > 0 {
+		next := g.GridCellSpaceToGridWorldSpace(a.path[0])
+		toNext := next.Sub(a.pos)
+		d := toNext.Magnitude()
+		if d <= step {
+			a.pos = next
+			a.path = a.path[1:]
+			step -= d
+		} else {
+			a.pos = a.pos.Add(toNext.Scale(step / d))
+			step = 0
+		}
+		moved = true
+	}
+	if len(a.path) == 0 && a.goal != nil && a.Cell(g) == *a.goal {
+		a.goal = nil
+	}
+	return moved
+}
+
+// tests if the agent's body covers world-space point p
+func (a *Agent) Contains(g *Grid, p pathfinding.Vec2D) bool {
+	return a.Bounds(g).Contains(p)
+}
+
+// the square the agent is drawn as
+func (a *Agent) Bounds(g *Grid) Rect2D {
+	return CenteredSquare(a.pos, AGENT_SIZE*g.CellWorldSize())
+}
@@ -0,0 +1,103 @@
+// Compares the node expansions and wall time of AStarPath and JumpPointPath
+// over random queries on a procedurally generated grid, checking that both
+// find paths of the same cost
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/dt-rush/go-sdl2-pathfinding/pathfinding"
+	"log"
+	"math/rand"
+	"time"
+)
+
+// accumulated statistics of one solver over all queries
+type stats struct {
+	name     string
+	found    int
+	expanded int
+	elapsed  time.Duration
+}
+
+func (s *stats) add(res pathfinding.PathResult) {
+	if res.Err == nil {
+		s.found++
+	}
+	s.expanded += res.Expanded
+	s.elapsed += res.Elapsed
+}
+
+func (s *stats) String() string {
+	return fmt.Sprintf("%-8s found %6d  expanded %10d  time %v",
+		s.name, s.found, s.expanded, s.elapsed)
+}
+
+// picks a random passable cell, giving up after ten tries per cell of the
+// grid (when it may well have none)
+func randomPassable(g *pathfinding.Grid, rng *rand.Rand) pathfinding.Position {
+	for i := 0; i < 10*g.W*g.H; i++ {
+		p := pathfinding.Position{X: rng.Intn(g.W), Y: rng.Intn(g.H)}
+		if !g.IsObstacle(p) {
+			return p
+		}
+	}
+	log.Fatalf("no passable cell found; try a lower -density\n")
+	return pathfinding.NOWHERE
+}
+
+func main() {
+	w := flag.Int("w", 512, "grid width in cells")
+	h := flag.Int("h", 512, "grid height in cells")
+	gen := flag.String("gen", "blobs", "terrain generator")
+	density := flag.Float64("density", 0.02,
+		"obstacle density for the blobs and noise generators")
+	connected := flag.Bool("connected", false,
+		"wall off all but the largest connected region")
+	queries := flag.Int("n", 200, "number of random queries")
+	seed := flag.Int64("seed", 1, "random seed")
+	flag.Parse()
+
+	var generator pathfinding.TerrainGenerator
+	switch *gen {
+	case "blobs":
+		generator = &pathfinding.BlobGenerator{Density: *density}
+	case "noise":
+		generator = &pathfinding.NoiseGenerator{Density: *density}
+	default:
+		if generator = pathfinding.GeneratorByName(*gen); generator == nil {
+			log.Fatalf("unknown generator %q\n", *gen)
+		}
+	}
+	if *connected {
+		generator = &pathfinding.ConnectedGenerator{
+			Generator: generator,
+			Movement:  pathfinding.EightConnected,
+		}
+	}
+	rng := rand.New(rand.NewSource(*seed))
+	grid := pathfinding.NewGrid(*w, *h)
+	grid.Cells = generator.Generate(*w, *h, rng)
+	grid.Terrain = append([]pathfinding.TerrainType(nil),
+		pathfinding.UniformTerrain...)
+	c := pathfinding.NewAStarPathComputer(grid)
+
+	astar := &stats{name: "A*"}
+	jps := &stats{name: "JPS"}
+	for i := 0; i < *queries; i++ {
+		start := randomPassable(grid, rng)
+		end := randomPassable(grid, rng)
+		a := c.AStarPath(start, end)
+		j := c.JumpPointPath(start, end)
+		if a.Err != j.Err || a.Cost != j.Cost {
+			log.Fatalf("%v -> %v: A* cost %d (%v), JPS cost %d (%v)\n",
+				start, end, a.Cost, a.Err, j.Cost, j.Err)
+		}
+		astar.add(a)
+		jps.add(j)
+	}
+	fmt.Printf("%d queries on a %dx%d %s grid\n",
+		*queries, *w, *h, generator.Name())
+	fmt.Println(astar)
+	fmt.Println(jps)
+}
@@ -0,0 +1,246 @@
+// Runs every query of a Moving AI scenario (.scen) file through the chosen
+// solvers on its map, reporting for each difficulty bucket how far the paths
+// found are from the optimal length, the node expansions spent and the time
+// taken, as CSV or JSON
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/dt-rush/go-sdl2-pathfinding/pathfinding"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// a named way of answering queries on a grid
+type solver struct {
+	name string
+	find func(start pathfinding.Position,
+		goal pathfinding.Position) pathfinding.PathResult
+}
+
+// builds the solvers named in a comma-separated list
+func makeSolvers(grid *pathfinding.Grid, names string, cluster int) []solver {
+	var solvers []solver
+	for _, name := range strings.Split(names, ",") {
+		switch name {
+		case "astar":
+			c := pathfinding.NewAStarPathComputer(grid)
+			solvers = append(solvers, solver{name, c.AStarPath})
+		case "jps":
+			c := pathfinding.NewAStarPathComputer(grid)
+			solvers = append(solvers, solver{name, c.JumpPointPath})
+		case "bidir":
+			c := pathfinding.NewAStarPathComputer(grid)
+			solvers = append(solvers,
+				solver{name, c.BidirectionalAStarPath})
+		case "hpa":
+			h := pathfinding.NewHPAPathfinder(grid, cluster)
+			solvers = append(solvers, solver{name, h.HPAPath})
+		case "dstar":
+			// a fresh planner per query, so no query benefits from the
+			// search of the one before
+			find := func(start pathfinding.Position,
+				goal pathfinding.Position) pathfinding.PathResult {
+				return pathfinding.NewDStarLitePlanner(grid).Plan(start, goal)
+			}
+			solvers = append(solvers, solver{name, find})
+		default:
+			log.Fatalf("unknown solver %q\n", name)
+		}
+	}
+	return solvers
+}
+
+// the statistics of one solver over the queries of one bucket
+type record struct {
+	Solver  string `json:"solver"`
+	Bucket  int    `json:"bucket"`
+	Queries int    `json:"queries"`
+	// queries for which a path was found
+	Solved int `json:"solved"`
+	// the length of the paths found minus the optimal length
+	MeanDeviation float64 `json:"mean_deviation"`
+	MaxDeviation  float64 `json:"max_deviation"`
+	MeanExpanded  float64 `json:"mean_expanded"`
+	// mean wall time per query, in microseconds
+	MeanMicros float64 `json:"mean_us"`
+	totalDev   float64
+	expanded   int
+	elapsed    time.Duration
+}
+
+func (r *record) add(s scenario, res pathfinding.PathResult) {
+	r.Queries++
+	r.expanded += res.Expanded
+	r.elapsed += res.Elapsed
+	if res.Err != nil {
+		return
+	}
+	r.Solved++
+	dev := pathLength(res.Path) - s.optimal
+	r.totalDev += dev
+	if math.Abs(dev) > math.Abs(r.MaxDeviation) {
+		r.MaxDeviation = dev
+	}
+}
+
+func (r *record) finish() {
+	if r.Solved > 0 {
+		r.MeanDeviation = r.totalDev / float64(r.Solved)
+	}
+	if r.Queries > 0 {
+		r.MeanExpanded = float64(r.expanded) / float64(r.Queries)
+		r.MeanMicros = float64(r.elapsed.Microseconds()) / float64(r.Queries)
+	}
+}
+
+// the length of a path with straight steps of length 1 and diagonal steps
+// of length sqrt(2), as the optimal lengths in .scen files are measured
+func pathLength(path []pathfinding.Position) float64 {
+	l := 0.0
+	for i := 1; i < len(path); i++ {
+		dx := float64(path[i].X - path[i-1].X)
+		dy := float64(path[i].Y - path[i-1].Y)
+		l += math.Sqrt(dx*dx + dy*dy)
+	}
+	return l
+}
+
+func loadMap(path string) *pathfinding.Grid {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	grid, err := pathfinding.LoadGrid(f)
+	if err != nil {
+		log.Fatalf("%s: %v\n", path, err)
+	}
+	// the scenarios' optimal lengths assume every passable cell costs the
+	// same
+	grid.Terrain = append([]pathfinding.TerrainType(nil),
+		pathfinding.UniformTerrain...)
+	return grid
+}
+
+func writeCSV(records []*record) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"solver", "bucket", "queries", "solved",
+		"mean_deviation", "max_deviation", "mean_expanded", "mean_us"})
+	for _, r := range records {
+		w.Write([]string{
+			r.Solver,
+			strconv.Itoa(r.Bucket),
+			strconv.Itoa(r.Queries),
+			strconv.Itoa(r.Solved),
+			strconv.FormatFloat(r.MeanDeviation, 'f', 4, 64),
+			strconv.FormatFloat(r.MaxDeviation, 'f', 4, 64),
+			strconv.FormatFloat(r.MeanExpanded, 'f', 1, 64),
+			strconv.FormatFloat(r.MeanMicros, 'f', 1, 64),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func writeJSON(records []*record) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+func main() {
+	mapPath := flag.String("map", "",
+		"the .map file (default: the map named in the .scen, in its directory)")
+	names := flag.String("solvers", "astar,jps",
+		"comma-separated solvers to run: astar, jps, bidir, hpa, dstar")
+	cluster := flag.Int("cluster", 16, "HPA* cluster size")
+	format := flag.String("format", "csv", "output format: csv or json")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [flags] file.scen\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *format != "csv" && *format != "json" {
+		log.Fatalf("unknown format %q\n", *format)
+	}
+	if *cluster < 1 {
+		log.Fatalf("bad cluster size %d\n", *cluster)
+	}
+
+	scenPath := flag.Arg(0)
+	f, err := os.Open(scenPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	scens, err := readScenarios(f)
+	f.Close()
+	if err != nil {
+		log.Fatalf("%s: %v\n", scenPath, err)
+	}
+	if len(scens) == 0 {
+		log.Fatalf("%s: no scenarios\n", scenPath)
+	}
+	if *mapPath == "" {
+		*mapPath = filepath.Join(filepath.Dir(scenPath),
+			filepath.Base(scens[0].mapName))
+	}
+	grid := loadMap(*mapPath)
+
+	solvers := makeSolvers(grid, *names, *cluster)
+	var records []*record
+	byKey := make(map[string]map[int]*record)
+	for _, s := range scens {
+		if s.width != grid.W || s.height != grid.H {
+			log.Fatalf("scenario for a %dx%d map, but %s is %dx%d\n",
+				s.width, s.height, *mapPath, grid.W, grid.H)
+		}
+		for _, sv := range solvers {
+			if byKey[sv.name] == nil {
+				byKey[sv.name] = make(map[int]*record)
+			}
+			r := byKey[sv.name][s.bucket]
+			if r == nil {
+				r = &record{Solver: sv.name, Bucket: s.bucket}
+				byKey[sv.name][s.bucket] = r
+				records = append(records, r)
+			}
+			r.add(s, sv.find(s.start, s.goal))
+		}
+	}
+	// by solver in the order given, then by bucket
+	order := make(map[string]int)
+	for i, sv := range solvers {
+		order[sv.name] = i
+	}
+	sort.Slice(records, func(i int, j int) bool {
+		if records[i].Solver != records[j].Solver {
+			return order[records[i].Solver] < order[records[j].Solver]
+		}
+		return records[i].Bucket < records[j].Bucket
+	})
+	for _, r := range records {
+		r.finish()
+	}
+	if *format == "csv" {
+		err = writeCSV(records)
+	} else {
+		err = writeJSON(records)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
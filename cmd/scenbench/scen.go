@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/dt-rush/go-sdl2-pathfinding/pathfinding"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// one query of a Moving AI scenario file
+type scenario struct {
+	bucket  int
+	mapName string
+	// the size of the map the query was made for
+	width  int
+	height int
+	start  pathfinding.Position
+	goal   pathfinding.Position
+	// the length of the shortest path, with diagonal steps of length
+	// sqrt(2) and no corner cutting
+	optimal float64
+}
+
+// reads a Moving AI .scen file: a "version 1" line followed by one line per
+// query of tab-separated fields
+//
+//	bucket map width height startx starty goalx goaly optimal
+func readScenarios(r io.Reader) ([]scenario, error) {
+	sc := bufio.NewScanner(r)
+	var scens []scenario
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "version") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) != 9 {
+			return nil, fmt.Errorf("scen line %d: %d fields, want 9",
+				line, len(fields))
+		}
+		var n [7]int
+		for i, j := range []int{0, 2, 3, 4, 5, 6, 7} {
+			v, err := strconv.Atoi(fields[j])
+			if err != nil {
+				return nil, fmt.Errorf("scen line %d: %v", line, err)
+			}
+			n[i] = v
+		}
+		optimal, err := strconv.ParseFloat(fields[8], 64)
+		if err != nil {
+			return nil, fmt.Errorf("scen line %d: %v", line, err)
+		}
+		scens = append(scens, scenario{
+			bucket:  n[0],
+			mapName: fields[1],
+			width:   n[1],
+			height:  n[2],
+			start:   pathfinding.Position{X: n[3], Y: n[4]},
+			goal:    pathfinding.Position{X: n[5], Y: n[6]},
+			optimal: optimal,
+		})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return scens, nil
+}
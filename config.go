@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"github.com/dt-rush/go-sdl2-pathfinding/pathfinding"
+	"log"
+	"strings"
+	"time"
+)
+
+// runtime settings of the demo, read from the command line
+type Config struct {
+	GridW  int
+	GridH  int
+	WorldW float64
+	WorldH float64
+	// node expansions the path queue may spend each frame
+	Budget int
+	// the file the grid is saved to and loaded from; JSON if it ends in
+	// .json, else the .map format
+	MapFile string
+	// the terrain generator and the seed it starts with
+	Generator pathfinding.TerrainGenerator
+	Seed      int64
+	// whether to keep only the largest connected region of the terrain
+	Connected bool
+}
+
+func ParseConfig() Config {
+	var c Config
+	flag.IntVar(&c.GridW, "w", GRID_CELLS_W, "grid width in cells")
+	flag.IntVar(&c.GridH, "h", GRID_CELLS_H, "grid height in cells")
+	flag.Float64Var(&c.WorldW, "worldw", GRID_WORLD_W,
+		"world-space width of the grid")
+	flag.Float64Var(&c.WorldH, "worldh", GRID_WORLD_H,
+		"world-space height of the grid")
+	flag.IntVar(&c.Budget, "budget", PATH_BUDGET,
+		"path search node expansions per frame")
+	flag.StringVar(&c.MapFile, "map", MAP_FILE,
+		"file to save the grid to (S key) and load it from (L key)")
+	gen := flag.String("gen", GENERATOR, "terrain generator: "+generatorNames())
+	flag.Int64Var(&c.Seed, "seed", 0, "terrain seed (0 for one from the clock)")
+	flag.BoolVar(&c.Connected, "connected", false,
+		"wall off all but the largest connected region of the terrain")
+	flag.Parse()
+	if c.GridW < 1 || c.GridH < 1 || c.WorldW <= 0 || c.WorldH <= 0 ||
+		c.Budget < 1 {
+		log.Fatalf("grid and world dimensions and budget must be positive\n")
+	}
+	if c.Generator = pathfinding.GeneratorByName(*gen); c.Generator == nil {
+		log.Fatalf("unknown generator %q\n", *gen)
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	return c
+}
+
+// the names of the terrain generators, for usage messages
+func generatorNames() string {
+	names := make([]string, len(pathfinding.Generators))
+	for i, gen := range pathfinding.Generators {
+		names[i] = gen.Name()
+	}
+	return strings.Join(names, ", ")
+}
@@ -1,11 +1,13 @@
 package main
 
-// world dimension of the grid (in world-space coordinates,
-// the dimension of the grid square that is the screen)
-const GRID_WORLD_DIMENSION = 1024
+// default world dimensions of the grid (in world-space coordinates,
+// the dimensions of the grid rectangle that is the screen)
+const GRID_WORLD_W = 1024
+const GRID_WORLD_H = 1024
 
-// the grid is made of GRID_CELL_DIMENSION x GRID_CELL_DIMENSION cells
-const GRID_CELL_DIMENSION = 12
+// by default the grid is made of GRID_CELLS_W x GRID_CELLS_H cells
+const GRID_CELLS_W = 12
+const GRID_CELLS_H = 12
 
 // visual constants
 const WINDOW_WIDTH = 640
@@ -13,13 +15,24 @@ const WINDOW_HEIGHT = 640
 const FONTSZ = 16
 const FPS = 60
 
-// dimensions of grid cells in pixels
-const GRIDCELL_PX_W = WINDOW_WIDTH / GRID_CELL_DIMENSION
-const GRIDCELL_PX_H = WINDOW_HEIGHT / GRID_CELL_DIMENSION
+// default node expansions path searches may spend per frame
+const PATH_BUDGET = 2000
 
-// dimensions of grid cells in world space
-const GRIDCELL_WORLD_W = GRID_WORLD_DIMENSION / GRID_CELL_DIMENSION
-const GRIDCELL_WORLD_H = GRID_WORLD_DIMENSION / GRID_CELL_DIMENSION
+// node expansions per frame while animating a search, slow enough to watch
+const ANIMATE_BUDGET = 1
 
-// density with which to populate obstacles
-const DENSITY = 0.05
+// agents' speed, in cells per second, and size, as a fraction of a cell
+const AGENT_SPEED = 4
+const AGENT_SIZE = 0.5
+
+// how long a refused endpoint stays marked
+const REJECT_MS = 500
+
+// how far, in cells, the start can see when its field of view is shown
+const FOV_RADIUS = 8
+
+// default file the grid is saved to and loaded from
+const MAP_FILE = "grid.json"
+
+// default terrain generator, by name
+const GENERATOR = "blobs"
@@ -1,25 +1,28 @@
 package main
 
 import (
+	"github.com/dt-rush/go-sdl2-pathfinding/pathfinding"
 	"github.com/veandco/go-sdl2/gfx"
 	"github.com/veandco/go-sdl2/sdl"
 )
 
-func drawPoint(r *sdl.Renderer, p Vec2D, c sdl.Color, sz int) {
-	sspx, sspy := GridWorldSpaceToScreenSpace(p)
-	r.SetDrawColor(c.R, c.G, c.B, 255)
-	r.FillRect(&sdl.Rect{
-		int32(sspx - sz/2),
-		int32(sspy - sz/2),
-		int32(sz), int32(sz)})
+func drawPoint(g *Grid, p pathfinding.Vec2D, c sdl.Color, sz int) {
+	sspx, sspy := g.GridWorldSpaceToScreenSpace(p)
+	g.r.SetDrawColor(c.R, c.G, c.B, 255)
+	g.r.FillRect(&sdl.Rect{
+		X: int32(sspx - sz/2),
+		Y: int32(sspy - sz/2),
+		W: int32(sz),
+		H: int32(sz)})
 }
 
-func drawVector(r *sdl.Renderer, pos Vec2D, v Vec2D, c sdl.Color) {
+func drawVector(g *Grid, pos pathfinding.Vec2D, v pathfinding.Vec2D,
+	c sdl.Color) {
 	// screen-space position
-	sspx, sspy := GridWorldSpaceToScreenSpace(pos)
+	sspx, sspy := g.GridWorldSpaceToScreenSpace(pos)
 	// screen-space vector tip
-	ssvtx, ssvty := GridWorldSpaceToScreenSpace(pos.Add(v))
-	gfx.LineColor(r,
+	ssvtx, ssvty := g.GridWorldSpaceToScreenSpace(pos.Add(v))
+	gfx.LineColor(g.r,
 		int32(sspx),
 		int32(sspy),
 		int32(ssvtx),
@@ -27,8 +30,34 @@ func drawVector(r *sdl.Renderer, pos Vec2D, v Vec2D, c sdl.Color) {
 		sdl.Color{R: c.R, G: c.G, B: c.B, A: 255})
 }
 
-func drawRect(r *sdl.Renderer, rect Rect2D, c sdl.Color) {
-	r.SetDrawColor(c.R, c.G, c.B, 255)
-	ssr := rect.ToScreenSpaceSdlRect()
-	r.FillRect(&ssr)
+func drawRect(g *Grid, rect Rect2D, c sdl.Color) {
+	g.r.SetDrawColor(c.R, c.G, c.B, 255)
+	ssr := rect.ToScreenSpaceSdlRect(g)
+	g.r.FillRect(&ssr)
+}
+
+// like drawRect, but blends the color over what's already drawn by its alpha
+func tintRect(g *Grid, rect Rect2D, c sdl.Color) {
+	g.r.SetDrawColor(c.R, c.G, c.B, c.A)
+	ssr := rect.ToScreenSpaceSdlRect(g)
+	g.r.FillRect(&ssr)
+}
+
+// draw text with its top-left corner at screen-space x, y
+func drawText(g *Grid, text string, x int, y int, c sdl.Color) {
+	surface, err := g.f.RenderUTF8Blended(text, c)
+	if err != nil {
+		panic(err)
+	}
+	defer surface.Free()
+	texture, err := g.r.CreateTextureFromSurface(surface)
+	if err != nil {
+		panic(err)
+	}
+	defer texture.Destroy()
+	g.r.Copy(texture, nil, &sdl.Rect{
+		X: int32(x),
+		Y: int32(y),
+		W: surface.W,
+		H: surface.H})
 }
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"github.com/dt-rush/go-sdl2-pathfinding/pathfinding"
+)
+
+// the largest brush, in cells across
+const MAX_BRUSH = 9
+
+// terrain kinds painted by the editor, chosen with the number keys 1-6
+var paintKinds = []int{
+	pathfinding.OBSTACLE,
+	pathfinding.ROAD,
+	pathfinding.FOREST,
+	pathfinding.MUD,
+	pathfinding.WATER,
+	pathfinding.TREES,
+}
+
+// a change of one cell's kind
+type cellEdit struct {
+	pos    pathfinding.Position
+	before int
+	after  int
+}
+
+// all the cell changes made by one click-drag, undone and redone together
+type stroke []cellEdit
+
+// paints terrain kinds onto a Grid with a square brush, keeping the strokes
+// made so they can be undone and redone
+type Editor struct {
+	grid *Grid
+	// the kind painted, an index into paintKinds
+	kind int
+	// the brush size, in cells across
+	brush int
+	// the stroke being painted, nil between strokes
+	cur stroke
+	// the cells cur has already changed, so each is recorded just once
+	touched map[pathfinding.Position]bool
+	undo    []stroke
+	redo    []stroke
+}
+
+func NewEditor(grid *Grid) *Editor {
+	return &Editor{grid: grid, brush: 1}
+}
+
+func (e *Editor) Kind() int {
+	return paintKinds[e.kind]
+}
+
+func (e *Editor) SetKind(i int) {
+	if i >= 0 && i < len(paintKinds) {
+		e.kind = i
+		fmt.Printf("painting %s\n", e.grid.Terrain[e.Kind()].Name)
+	}
+}
+
+// grow (d > 0) or shrink (d < 0) the brush
+func (e *Editor) ResizeBrush(d int) {
+	e.brush += d
+	if e.brush < 1 {
+		e.brush = 1
+	}
+	if e.brush > MAX_BRUSH {
+		e.brush = MAX_BRUSH
+	}
+	fmt.Printf("brush size %d\n", e.brush)
+}
+
+// paint kind under the brush centered on p, as part of the current stroke
+// (starting one if need be). Returns true if any cell changed. The start
+// and end are never walled in
+func (e *Editor) Paint(p pathfinding.Position, kind int) bool {
+	if e.cur == nil {
+		e.cur = stroke{}
+		e.touched = make(map[pathfinding.Position]bool)
+	}
+	changed := false
+	r := e.brush / 2
+	for x := p.X - r; x < p.X-r+e.brush; x++ {
+		for y := p.Y - r; y < p.Y-r+e.brush; y++ {
+			c := pathfinding.Position{X: x, Y: y}
+			if !e.grid.InGrid(c) || e.blocksEndpoint(c, kind) {
+				continue
+			}
+			before := e.grid.Cells[x][y]
+			if before == kind {
+				continue
+			}
+			if !e.touched[c] {
+				e.touched[c] = true
+				e.cur = append(e.cur, cellEdit{pos: c, before: before})
+			}
+			e.grid.Cells[x][y] = kind
+			changed = true
+		}
+	}
+	return changed
+}
+
+// finish the current stroke, making it the one to undo next
+func (e *Editor) EndStroke() {
+	if e.cur == nil {
+		return
+	}
+	if len(e.cur) > 0 {
+		// record what each cell ended up as, for redo
+		for i := range e.cur {
+			p := e.cur[i].pos
+			e.cur[i].after = e.grid.Cells[p.X][p.Y]
+		}
+		e.undo = append(e.undo, e.cur)
+		e.redo = e.redo[:0]
+	}
+	e.cur = nil
+	e.touched = nil
+}
+
+// undo the last stroke. Returns true if there was one to undo
+func (e *Editor) Undo() bool {
+	e.EndStroke()
+	if len(e.undo) == 0 {
+		return false
+	}
+	s := e.undo[len(e.undo)-1]
+	e.undo = e.undo[:len(e.undo)-1]
+	for i := len(s) - 1; i >= 0; i-- {
+		e.set(s[i].pos, s[i].before)
+	}
+	e.redo = append(e.redo, s)
+	return true
+}
+
+// redo the last stroke undone. Returns true if there was one to redo
+func (e *Editor) Redo() bool {
+	e.EndStroke()
+	if len(e.redo) == 0 {
+		return false
+	}
+	s := e.redo[len(e.redo)-1]
+	e.redo = e.redo[:len(e.redo)-1]
+	for _, ce := range s {
+		e.set(ce.pos, ce.after)
+	}
+	e.undo = append(e.undo, s)
+	return true
+}
+
+// set the kind of the cell at p, unless that would wall in the start or end,
+// which may have been placed there since
+func (e *Editor) set(p pathfinding.Position, kind int) {
+	if !e.blocksEndpoint(p, kind) {
+		e.grid.Cells[p.X][p.Y] = kind
+	}
+}
+
+// tests if painting the cell at p with kind would wall in the start or
+// end
+func (e *Editor) blocksEndpoint(p pathfinding.Position, kind int) bool {
+	if e.grid.Terrain[kind].Cost != pathfinding.IMPASSABLE {
+		return false
+	}
+	return (e.grid.start != nil && *e.grid.start == p) ||
+		(e.grid.end != nil && *e.grid.end == p)
+}
@@ -3,6 +3,7 @@ package main
 
 import (
 	"fmt"
+	"github.com/dt-rush/go-sdl2-pathfinding/pathfinding"
 	"github.com/veandco/go-sdl2/sdl"
 	"github.com/veandco/go-sdl2/ttf"
 	"time"
@@ -14,24 +15,86 @@ const (
 	MODE_PLACING_END   = iota
 )
 
+// solvers for the start-end path, cycled through with the V key
+const (
+	SOLVER_ASTAR      = iota
+	SOLVER_THETA      = iota
+	SOLVER_LAZY_THETA = iota
+	SOLVER_BIDIR      = iota
+	N_SOLVERS         = iota
+)
+
+var solverNames = []string{
+	"A*", "Theta*", "Lazy Theta*", "Bidirectional A*"}
+
+// movement models cycled through with the M key
+var movementModels = []*pathfinding.GridMovement{
+	pathfinding.EightConnected,
+	pathfinding.EightConnectedCutting,
+	pathfinding.FourConnected,
+	pathfinding.SixteenConnected,
+}
+
 type Game struct {
-	grid      *Grid
-	mode      int
-	apc       *AStarPathComputer
-	fpsTicker *time.Ticker
-	r         *sdl.Renderer
-	f         *ttf.Font
+	grid     *Grid
+	mode     int
+	movement int
+	// whether clicks paint terrain rather than place the start and end
+	editing bool
+	editor  *Editor
+	// the queue of the start-end search, whose computer the overlay
+	// draws, and a queue of its own for the agents' searches, which would
+	// otherwise show up in the overlay
+	pq      *pathfinding.PathQueue
+	agentQ  *pathfinding.PathQueue
+	ticket  *pathfinding.PathTicket
+	budget  int
+	animate bool
+	mapFile string
+	// the generator (index into pathfinding.Generators) and seed of the
+	// terrain
+	generator int
+	seed      int64
+	connected bool
+	// whether endpoints clicked on impassable cells move to the nearest
+	// passable one, rather than being refused
+	snap bool
+	// when the mark on a refused endpoint's cell should be cleared
+	rejectUntil time.Time
+	// the solver for the start-end path, and the computer the solvers
+	// other than A* run on, apart from the path queue's
+	solver int
+	direct *pathfinding.AStarPathComputer
+	// whether clicks select, add and command agents
+	commanding  bool
+	nextAgentID int
+	lastUpdate  time.Time
+	fpsTicker   *time.Ticker
+	r           *sdl.Renderer
+	f           *ttf.Font
 }
 
-func NewGame(r *sdl.Renderer, f *ttf.Font) *Game {
-	grid := NewGrid(r)
-	return &Game{
-		grid:      grid,
-		apc:       NewAStarPathComputer(grid),
-		fpsTicker: time.NewTicker(time.Millisecond * (1000 / FPS)),
-		r:         r,
-		f:         f,
+func NewGame(r *sdl.Renderer, f *ttf.Font, cfg Config) *Game {
+	grid := NewGrid(r, f, cfg)
+	pq := pathfinding.NewPathQueue(grid.Grid, cfg.Budget)
+	grid.search = pq.Computer
+	g := &Game{
+		grid:       grid,
+		editor:     NewEditor(grid),
+		pq:         pq,
+		agentQ:     pathfinding.NewPathQueue(grid.Grid, cfg.Budget),
+		budget:     cfg.Budget,
+		mapFile:    cfg.MapFile,
+		generator:  generatorIndex(cfg.Generator),
+		seed:       cfg.Seed,
+		connected:  cfg.Connected,
+		lastUpdate: time.Now(),
+		fpsTicker:  time.NewTicker(time.Millisecond * (1000 / FPS)),
+		r:          r,
+		f:          f,
 	}
+	g.Relabel()
+	return g
 }
 
 func (g *Game) gameloop() int {
@@ -52,6 +115,28 @@ gameloop:
 		if !g.HandleEvents() {
 			break gameloop
 		}
+		// clear the mark on a refused endpoint once it's been seen
+		if g.grid.rejected != nil && time.Now().After(g.rejectUntil) {
+			g.grid.rejected = nil
+			g.grid.UpdateTexture()
+		}
+		// advance any path search in progress, redrawing to show how far
+		// it's got
+		g.pq.Tick()
+		g.agentQ.Tick()
+		redraw := g.ticket != nil
+		// walk the agents
+		now := time.Now()
+		dt := now.Sub(g.lastUpdate).Seconds()
+		g.lastUpdate = now
+		for _, a := range g.grid.agents {
+			if a.Update(g.grid, dt) {
+				redraw = true
+			}
+		}
+		if redraw {
+			g.grid.UpdateTexture()
+		}
 		// sleep
 		sdl.Delay(1000 / (2 * FPS))
 	}
@@ -69,6 +154,8 @@ func (g *Game) HandleEvents() bool {
 			g.HandleKeyEvents(e)
 		case *sdl.MouseButtonEvent:
 			g.HandleMouseButtonEvents(e.(*sdl.MouseButtonEvent))
+		case *sdl.MouseMotionEvent:
+			g.HandleMouseMotionEvents(e.(*sdl.MouseMotionEvent))
 		}
 	}
 	return true
@@ -98,16 +185,183 @@ func (g *Game) HandleKeyEvents(e sdl.Event) {
 			if ke.Keysym.Sym == sdl.K_g {
 				fmt.Println("pressed G")
 			}
+			// cycle the movement model and re-path with it
+			if ke.Keysym.Sym == sdl.K_m {
+				g.movement = (g.movement + 1) % len(movementModels)
+				g.pq.Computer.Movement = movementModels[g.movement]
+				g.agentQ.Computer.Movement = movementModels[g.movement]
+				g.Relabel()
+				fmt.Printf("movement: %s\n", movementModels[g.movement].Name)
+				g.UpdatePath()
+				g.grid.UpdateTexture()
+			}
+			// toggle animating searches, running them slowly enough to
+			// watch the open and closed sets grow
+			if ke.Keysym.Sym == sdl.K_a {
+				g.animate = !g.animate
+				if g.animate {
+					g.pq.Budget = ANIMATE_BUDGET
+				} else {
+					g.pq.Budget = g.budget
+				}
+				fmt.Printf("animate: %v\n", g.animate)
+			}
+			// cycle what's drawn of the search over the cells
+			if ke.Keysym.Sym == sdl.K_o {
+				g.grid.overlay = (g.grid.overlay + 1) % N_OVERLAYS
+				g.grid.UpdateTexture()
+			}
+			// save the grid to, or load it from, the map file
+			if ke.Keysym.Sym == sdl.K_s {
+				if err := g.SaveMap(); err != nil {
+					fmt.Printf("couldn't save %s: %v\n", g.mapFile, err)
+				}
+			}
+			if ke.Keysym.Sym == sdl.K_l {
+				if err := g.LoadMap(); err != nil {
+					fmt.Printf("couldn't load %s: %v\n", g.mapFile, err)
+				}
+			}
+			// switch to the next terrain generator, or a new seed
+			if ke.Keysym.Sym == sdl.K_t {
+				g.generator = (g.generator + 1) % len(pathfinding.Generators)
+				g.Regenerate()
+			}
+			if ke.Keysym.Sym == sdl.K_n {
+				g.seed = time.Now().UnixNano()
+				g.Regenerate()
+			}
+			// toggle snapping endpoints to passable cells
+			if ke.Keysym.Sym == sdl.K_x {
+				g.snap = !g.snap
+				fmt.Printf("snap endpoints: %v\n", g.snap)
+			}
+			// toggle walling off all but the largest connected region
+			if ke.Keysym.Sym == sdl.K_c {
+				g.connected = !g.connected
+				g.Regenerate()
+			}
+			// cycle the solver for the start-end path
+			if ke.Keysym.Sym == sdl.K_v {
+				g.solver = (g.solver + 1) % N_SOLVERS
+				fmt.Printf("solver: %s\n", solverNames[g.solver])
+				g.UpdatePath()
+				g.grid.UpdateTexture()
+			}
+			// cycle how the start-end path is smoothed
+			if ke.Keysym.Sym == sdl.K_h {
+				g.grid.smoothing = (g.grid.smoothing + 1) % N_SMOOTHINGS
+				fmt.Printf("path: %s\n", smoothingNames[g.grid.smoothing])
+				g.grid.Smooth()
+				g.grid.UpdateTexture()
+			}
+			// toggle shading what the start can't see
+			if ke.Keysym.Sym == sdl.K_f {
+				g.grid.showFOV = !g.grid.showFOV
+				fmt.Printf("field of view: %v\n", g.grid.showFOV)
+				g.grid.UpdateTexture()
+			}
+			// toggle commanding agents
+			if ke.Keysym.Sym == sdl.K_p {
+				g.commanding = !g.commanding
+				fmt.Printf("commanding agents: %v\n", g.commanding)
+			}
+			// toggle the editor
+			if ke.Keysym.Sym == sdl.K_e {
+				g.editing = !g.editing
+				g.editor.EndStroke()
+				fmt.Printf("editing: %v\n", g.editing)
+			}
+			if g.editing {
+				g.HandleEditorKeys(ke)
+			}
+		}
+	}
+}
+
+// replace the terrain with that of the current generator and seed,
+// clearing the start and end
+func (g *Game) Regenerate() {
+	gen := terrainGenerator(pathfinding.Generators[g.generator], g.connected)
+	g.grid.Clear()
+	g.ClearAgents()
+	g.grid.Cells = pathfinding.GenerateTerrain(gen, g.grid.W, g.grid.H, g.seed)
+	g.editor = NewEditor(g.grid)
+	g.mode = MODE_PLACING_START
+	fmt.Printf("terrain: %s, seed %d\n", gen.Name(), g.seed)
+	g.GridEdited()
+}
+
+// the generator to make terrain with: gen itself, or if connected, gen
+// keeping only its largest region connected under the default movement
+func terrainGenerator(gen pathfinding.TerrainGenerator,
+	connected bool) pathfinding.TerrainGenerator {
+	if !connected {
+		return gen
+	}
+	return &pathfinding.ConnectedGenerator{
+		Generator: gen,
+		Movement:  movementModels[0],
+	}
+}
+
+func generatorIndex(gen pathfinding.TerrainGenerator) int {
+	for i, other := range pathfinding.Generators {
+		if other == gen {
+			return i
+		}
+	}
+	return 0
+}
+
+// handle keyboard input for the editor: 1-6 choose the kind painted, [ and
+// ] shrink and grow the brush, Z undoes and Y redoes
+func (g *Game) HandleEditorKeys(ke *sdl.KeyboardEvent) {
+	switch ke.Keysym.Sym {
+	case sdl.K_1, sdl.K_2, sdl.K_3, sdl.K_4, sdl.K_5, sdl.K_6:
+		g.editor.SetKind(int(ke.Keysym.Sym - sdl.K_1))
+	case sdl.K_LEFTBRACKET:
+		g.editor.ResizeBrush(-1)
+	case sdl.K_RIGHTBRACKET:
+		g.editor.ResizeBrush(1)
+	case sdl.K_z:
+		if g.editor.Undo() {
+			g.GridEdited()
+		}
+	case sdl.K_y:
+		if g.editor.Redo() {
+			g.GridEdited()
 		}
 	}
 }
 
 // handle mouse input
 func (g *Game) HandleMouseButtonEvents(me *sdl.MouseButtonEvent) {
-	p := MouseButtonEventToGridCellPosition(me)
+	p := MouseButtonEventToGridCellPosition(g.grid, me)
+	if g.editing {
+		// left button paints, right button erases, until released
+		switch {
+		case me.Type == sdl.MOUSEBUTTONUP:
+			g.editor.EndStroke()
+		case me.Button == sdl.BUTTON_LEFT:
+			g.PaintAt(p, g.editor.Kind())
+		case me.Button == sdl.BUTTON_RIGHT:
+			g.PaintAt(p, pathfinding.EMPTY)
+		}
+		return
+	}
 	if me.Type != sdl.MOUSEBUTTONDOWN {
 		return
 	}
+	if g.commanding {
+		g.HandleAgentClick(me, p)
+		return
+	}
+	p, ok := g.EndpointAt(p)
+	if !ok {
+		g.Reject(p)
+		return
+	}
 	// place either start or end
 	if g.mode == MODE_PLACING_START {
 		// if placing start, clear any prior grid data
@@ -118,24 +372,209 @@ func (g *Game) HandleMouseButtonEvents(me *sdl.MouseButtonEvent) {
 	}
 	// mode is toggled between start/end whenever a click event is processed
 	g.mode = (g.mode + 1) % 2
-	// if g.grid.start and g.grid.end are defined, compute the path
-	if g.grid.start != nil && g.grid.end != nil {
-		g.grid.path = g.grid.path[:0]
-		path := g.apc.AStarPath(*g.grid.start, *g.grid.end)
-		if len(path) != 0 {
-			path := make([]Position, 0)
-			cur := g.apc.endNode
-			for cur != nil {
-				path = append(path, cur.Pos)
-				cur = cur.From
-			}
-			for i, _ := range path {
-				if i != len(path)-1 {
-					g.grid.path = append(g.grid.path,
-						PositionPair{path[i], path[i+1]})
-				}
-			}
+	g.UpdatePath()
+	g.grid.UpdateTexture()
+}
+
+// in commanding mode, a left click selects the agent clicked on, or adds
+// one in the cell clicked if there's none there, and a right click sends
+// the selected agent to the cell clicked
+func (g *Game) HandleAgentClick(
+	me *sdl.MouseButtonEvent, p pathfinding.Position) {
+	switch me.Button {
+	case sdl.BUTTON_LEFT:
+		if a := g.grid.AgentAt(MouseButtonEventToVec2D(g.grid, me)); a != nil {
+			g.grid.selected = a
+		} else if p, ok := g.EndpointAt(p); ok {
+			a := NewAgent(g.grid, g.nextAgentID, p)
+			g.nextAgentID++
+			g.grid.agents = append(g.grid.agents, a)
+			g.grid.selected = a
+		} else {
+			g.Reject(p)
+			return
+		}
+	case sdl.BUTTON_RIGHT:
+		if g.grid.selected == nil {
+			return
+		}
+		if p, ok := g.EndpointAt(p); ok {
+			g.Command(g.grid.selected, p)
+		} else {
+			g.Reject(p)
+			return
 		}
 	}
 	g.grid.UpdateTexture()
 }
+
+// queue a search for a path taking the agent to goal, replacing any it's
+// waiting on. Meanwhile the agent carries on to the next cell of its path,
+// from which the new path starts
+func (g *Game) Command(a *Agent, goal pathfinding.Position) {
+	if a.ticket != nil {
+		a.ticket.Cancel()
+	}
+	a.goal = &goal
+	start := a.Cell(g.grid)
+	if len(a.path) > 0 {
+		start = a.path[0]
+		a.path = a.path[:1]
+	}
+	a.ticket = g.agentQ.Submit(
+		pathfinding.PathQuery{Start: start, End: goal},
+		0, func(res pathfinding.PathResult) { g.AgentPath(a, res) })
+}
+
+// called by the path queue when a search for an agent finishes
+func (g *Game) AgentPath(a *Agent, res pathfinding.PathResult) {
+	if res.Err == pathfinding.ErrCancelled {
+		return
+	}
+	a.ticket = nil
+	if res.Err != nil {
+		fmt.Printf("agent %d: %v\n", a.id, res.Err)
+		a.goal = nil
+		return
+	}
+	a.path = res.Path
+	g.grid.UpdateTexture()
+}
+
+// remove all agents, cancelling their searches
+func (g *Game) ClearAgents() {
+	for _, a := range g.grid.agents {
+		if a.ticket != nil {
+			a.ticket.Cancel()
+		}
+	}
+	g.grid.agents = nil
+	g.grid.selected = nil
+}
+
+// where an endpoint placed at p goes: p itself if it's passable, else the
+// nearest passable cell if snapping. Returns false if it can't go anywhere
+func (g *Game) EndpointAt(p pathfinding.Position) (pathfinding.Position, bool) {
+	if g.grid.InGrid(p) && !g.grid.IsObstacle(p) {
+		return p, true
+	}
+	if g.snap {
+		return g.grid.NearestPassable(p)
+	}
+	return p, false
+}
+
+// mark p for a moment to show an endpoint can't go there
+func (g *Game) Reject(p pathfinding.Position) {
+	fmt.Printf("can't place an endpoint at %v\n", p)
+	if !g.grid.InGrid(p) {
+		return
+	}
+	g.grid.rejected = &p
+	g.rejectUntil = time.Now().Add(REJECT_MS * time.Millisecond)
+	g.grid.UpdateTexture()
+}
+
+// handle mouse motion, which paints while dragging in the editor
+func (g *Game) HandleMouseMotionEvents(me *sdl.MouseMotionEvent) {
+	if !g.editing {
+		return
+	}
+	p := MouseMotionEventToGridCellPosition(g.grid, me)
+	if me.State&sdl.BUTTON_LMASK != 0 {
+		g.PaintAt(p, g.editor.Kind())
+	} else if me.State&sdl.BUTTON_RMASK != 0 {
+		g.PaintAt(p, pathfinding.EMPTY)
+	}
+}
+
+// paint kind with the editor's brush at p
+func (g *Game) PaintAt(p pathfinding.Position, kind int) {
+	if g.editor.Paint(p, kind) {
+		g.GridEdited()
+	}
+}
+
+// re-path and redraw after the cells have changed
+func (g *Game) GridEdited() {
+	g.Relabel()
+	for _, a := range g.grid.agents {
+		if a.goal != nil {
+			g.Command(a, *a.goal)
+		}
+	}
+	g.UpdatePath()
+	g.grid.UpdateTexture()
+}
+
+// label the grid's connected components for the current movement model,
+// so searches between unconnected cells fail at once
+func (g *Game) Relabel() {
+	comps := pathfinding.NewComponents(
+		g.grid.Grid, movementModels[g.movement])
+	g.pq.Computer.Components = comps
+	g.agentQ.Computer.Components = comps
+}
+
+// if g.grid.start and g.grid.end are defined, queue a search for the path
+// (replacing any search still queued), which the game loop runs a little
+// each frame
+func (g *Game) UpdatePath() {
+	if g.ticket != nil {
+		g.ticket.Cancel()
+		g.ticket = nil
+	}
+	g.grid.path = g.grid.path[:0]
+	g.grid.smoothed = nil
+	g.grid.showSearch = false
+	if g.grid.start == nil || g.grid.end == nil {
+		return
+	}
+	if g.solver != SOLVER_ASTAR {
+		// only A* is resumable, so the others run at once
+		if g.direct == nil || g.direct.Grid != g.grid.Grid {
+			g.direct = pathfinding.NewAStarPathComputer(g.grid.Grid)
+		}
+		start, end := *g.grid.start, *g.grid.end
+		switch g.solver {
+		case SOLVER_THETA:
+			g.ShowPath(g.direct.ThetaStarPath(start, end))
+		case SOLVER_LAZY_THETA:
+			g.ShowPath(g.direct.LazyThetaStarPath(start, end))
+		case SOLVER_BIDIR:
+			g.ShowPath(g.direct.BidirectionalAStarPathWithOptions(
+				start, end, pathfinding.SearchOptions{
+					Movement: movementModels[g.movement],
+				}))
+		}
+		return
+	}
+	g.grid.showSearch = true
+	g.ticket = g.pq.Submit(
+		pathfinding.PathQuery{Start: *g.grid.start, End: *g.grid.end},
+		0, g.ShowPath)
+}
+
+// called by the path queue when a search finishes
+func (g *Game) ShowPath(res pathfinding.PathResult) {
+	if res.Err == pathfinding.ErrCancelled {
+		return
+	}
+	g.ticket = nil
+	if res.Err != nil {
+		fmt.Println(res.Err)
+		return
+	}
+	fmt.Printf("path cost %d, %d expanded, %d generated in %v\n",
+		res.Cost, res.Expanded, res.Generated, res.Elapsed)
+	if res.Waypoints != nil {
+		fmt.Printf("%d waypoints, length %.1f\n",
+			len(res.Waypoints), res.Length)
+	}
+	for i := 1; i < len(res.Path); i++ {
+		g.grid.path = append(g.grid.path,
+			PositionPair{res.Path[i-1], res.Path[i]})
+	}
+	g.grid.Smooth()
+	g.grid.UpdateTexture()
+}
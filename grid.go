@@ -1,50 +1,68 @@
 package main
 
 import (
-	"errors"
+	"fmt"
+	"github.com/dt-rush/go-sdl2-pathfinding/pathfinding"
 	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
 )
 
-// special value used for the "From" of the start node
-var NOWHERE = Position{-1, -1}
-
-// deltas = neighbor x, y offsets
-//
-//   Y ^
-//     |
-//     |    -1,  1     0,  1     1,  1
-//     |
-//     |    -1,  0     [cur]     1,  0
-//     |
-//     |    -1, -1     0, -1     1, -1
-//     |
-//     |
-//      --------------------------------->
-//                                       X
-var deltas = [][2]int{
-	[2]int{-1, 1},
-	[2]int{0, 1},
-	[2]int{1, 1},
-	[2]int{-1, 0},
-	[2]int{1, 0},
-	[2]int{-1, -1},
-	[2]int{0, -1},
-	[2]int{1, -1},
-}
+// what's drawn over the cells of a search, cycled through with the O key
+const (
+	// nothing
+	OVERLAY_NONE = iota
+	// open and closed cells tinted
+	OVERLAY_SETS = iota
+	// open and closed cells tinted, labelled with their G, H and F
+	OVERLAY_COSTS = iota
+	// closed cells colored by when they were expanded, blue to red
+	OVERLAY_HEATMAP = iota
+	N_OVERLAYS      = iota
+)
+
+// how the start-end path is drawn, cycled through with the H key
+const (
+	// through the center of every cell of the path
+	SMOOTHING_NONE = iota
+	// string pulled, then with each pathfinding.CURVE_* kind fitted
+	SMOOTHING_PULLED      = iota
+	SMOOTHING_CATMULL_ROM = iota
+	SMOOTHING_BEZIER      = iota
+	N_SMOOTHINGS          = iota
+)
 
+var smoothingNames = []string{
+	"raw", "string pulled", "Catmull-Rom", "Bezier"}
+
+// the demo's view of a pathfinding.Grid: the cells themselves plus the
+// start, end and path the user has placed, drawn to an SDL texture
 type Grid struct {
-	W     int
-	H     int
-	Cells [][]int
-	start *Position
-	end   *Position
+	*pathfinding.Grid
+	start *pathfinding.Position
+	end   *pathfinding.Position
 	path  []PositionPair
-	r     *sdl.Renderer
-	st    *sdl.Texture
+	// the path smoothed as chosen by smoothing, in world space
+	smoothed  []pathfinding.Vec2D
+	smoothing int
+	// a cell an endpoint was just refused at, marked with a cross
+	rejected *pathfinding.Position
+	agents   []*Agent
+	selected *Agent
+	// the computer running the current search, whose state is drawn over
+	// the cells according to overlay if showSearch is set
+	search     *pathfinding.AStarPathComputer
+	showSearch bool
+	overlay    int
+	// whether cells the start can't see are shaded
+	showFOV bool
+	r       *sdl.Renderer
+	f       *ttf.Font
+	st      *sdl.Texture
 }
 
-// Construct a new grid, along with its SDL texture, generating random terrain
-func NewGrid(r *sdl.Renderer) *Grid {
+// Construct a new grid of the configured size, along with its SDL texture,
+// generating its terrain with the configured generator and seed
+func NewGrid(r *sdl.Renderer, f *ttf.Font, cfg Config) *Grid {
 	st, err := r.CreateTexture(
 		sdl.PIXELFORMAT_RGBA8888,
 		sdl.TEXTUREACCESS_TARGET,
@@ -53,12 +71,17 @@ func NewGrid(r *sdl.Renderer) *Grid {
 	if err != nil {
 		panic(err)
 	}
+	pg := pathfinding.NewGrid(cfg.GridW, cfg.GridH)
+	pg.WorldW = cfg.WorldW
+	pg.WorldH = cfg.WorldH
+	gen := terrainGenerator(cfg.Generator, cfg.Connected)
+	pg.Cells = pathfinding.GenerateTerrain(gen, pg.W, pg.H, cfg.Seed)
 	g := Grid{
-		W:     GRID_CELL_DIMENSION,
-		H:     GRID_CELL_DIMENSION,
-		Cells: MakeTerrain(GRID_CELL_DIMENSION, GRID_CELL_DIMENSION),
-		r:     r,
-		st:    st,
+		Grid:    pg,
+		overlay: OVERLAY_SETS,
+		r:       r,
+		f:       f,
+		st:      st,
 	}
 	g.UpdateTexture()
 	return &g
@@ -67,65 +90,33 @@ func NewGrid(r *sdl.Renderer) *Grid {
 // delete the saved path, start, and end data
 func (g *Grid) Clear() {
 	g.path = g.path[:0]
-	if g.start != nil {
-		g.Cells[g.start.X][g.start.Y] = EMPTY
-		g.start = nil
-	}
-	if g.end != nil {
-		g.Cells[g.end.X][g.end.Y] = EMPTY
-		g.end = nil
-	}
-}
-
-// tests if a position is in the grid bounds
-func (g *Grid) InGrid(p Position) bool {
-	return p.X >= 0 && p.X < GRID_CELL_DIMENSION &&
-		p.Y >= 0 && p.Y < GRID_CELL_DIMENSION
-}
-
-// tests if a position contains an obstacle
-func (g *Grid) IsObstacle(p Position) bool {
-	return g.Cells[p.X][p.Y] == OBSTACLE
-}
-
-// returns the neighbor position given an offset 'delta' or error if not a valid
-// neighbor (returns error on cross-corners)
-func (g *Grid) NbrOf(cur Position, delta [2]int) (
-	pos Position, dist int, err error) {
-	nbr := Position{
-		cur.X + delta[0],
-		cur.Y + delta[1],
-	}
-	if !g.InGrid(nbr) ||
-		g.IsObstacle(nbr) ||
-		// excludes cells which cross an obstacle on the corner
-		(delta[0]*delta[1] != 0 &&
-			g.Cells[cur.X][nbr.Y] == OBSTACLE ||
-			g.Cells[nbr.X][cur.Y] == OBSTACLE) {
-		return NOWHERE, -1, errors.New("invalid neighbor")
-	} else {
-		// dist is an integer expression of the distance from
-		// cur to the neighbor cell we're looking at here.
-		// if either x or y offset is 0, we're moving straight,
-		// so put 10. Otherwise we're moving diagonal, so put 14
-		// (these are 1 and sqrt(2), but made into integers for speed)
-		if delta[0]*delta[1] == 0 {
-			dist = 10
-		} else {
-			dist = 14
-		}
-		return nbr, dist, nil
-	}
+	g.smoothed = nil
+	g.start = nil
+	g.end = nil
 }
 
-func (g *Grid) SetStart(start Position) {
+// the start and end are kept apart from the cells, leaving the terrain
+// under them as it is
+func (g *Grid) SetStart(start pathfinding.Position) {
 	g.start = &start
-	g.Cells[start.X][start.Y] = START
 }
 
-func (g *Grid) SetEnd(end Position) {
+func (g *Grid) SetEnd(end pathfinding.Position) {
 	g.end = &end
-	g.Cells[end.X][end.Y] = END
+}
+
+// smooth the path as chosen by smoothing
+func (g *Grid) Smooth() {
+	g.smoothed = nil
+	if g.smoothing == SMOOTHING_NONE || len(g.path) == 0 {
+		return
+	}
+	cells := []pathfinding.Position{g.path[0].p1}
+	for _, pp := range g.path {
+		cells = append(cells, pp.p2)
+	}
+	g.smoothed = pathfinding.SmoothPath(g.Grid, cells,
+		g.smoothing-SMOOTHING_PULLED+pathfinding.CURVE_NONE)
 }
 
 // redraw the texture according to current state
@@ -135,45 +126,208 @@ func (g *Grid) UpdateTexture() {
 	g.r.SetDrawColor(0, 0, 0, 0)
 	g.r.Clear()
 	g.DrawGrid()
+	g.DrawSearch()
+	g.DrawFOV()
 	g.DrawPath()
+	g.DrawAgents()
+	g.DrawEndpoints()
 }
 
-// draw the grid cells (EMPTY, OBSTACLE, START, END) to `st`
+// draw the grid cells (terrain kinds) to `st`
 func (g *Grid) DrawGrid() {
-	for x := 0; x < GRID_CELL_DIMENSION; x++ {
-		for y := 0; y < GRID_CELL_DIMENSION; y++ {
+	cw := g.CellWorldW()
+	ch := g.CellWorldH()
+	for x := 0; x < g.W; x++ {
+		for y := 0; y < g.H; y++ {
 			var c sdl.Color
 			kind := g.Cells[x][y]
 			switch kind {
-			case EMPTY:
+			case pathfinding.EMPTY:
 				c = sdl.Color{R: 0, G: 0, B: 0}
-			case OBSTACLE:
+			case pathfinding.OBSTACLE:
 				c = sdl.Color{R: 255, G: 0, B: 0}
-			case START:
+			case pathfinding.START:
 				c = sdl.Color{R: 0, G: 255, B: 0}
-			case END:
+			case pathfinding.END:
 				c = sdl.Color{R: 0, G: 255, B: 255}
+			case pathfinding.ROAD:
+				c = sdl.Color{R: 96, G: 96, B: 96}
+			case pathfinding.FOREST:
+				c = sdl.Color{R: 0, G: 96, B: 0}
+			case pathfinding.MUD:
+				c = sdl.Color{R: 96, G: 64, B: 32}
+			case pathfinding.WATER:
+				c = sdl.Color{R: 0, G: 64, B: 160}
+			case pathfinding.TREES:
+				c = sdl.Color{R: 0, G: 48, B: 24}
 			}
 
-			drawRect(g.r,
-				Rect2D{
-					float64(x * GRIDCELL_WORLD_W),
-					float64(y * GRIDCELL_WORLD_H),
-					GRIDCELL_WORLD_W,
-					GRIDCELL_WORLD_H},
+			drawRect(g,
+				Rect2D{float64(x) * cw, float64(y) * ch, cw, ch},
 				c)
 		}
 	}
 }
 
+// shade the cells outside the start's field of view
+func (g *Grid) DrawFOV() {
+	if !g.showFOV || g.start == nil {
+		return
+	}
+	cw := g.CellWorldW()
+	ch := g.CellWorldH()
+	vis := g.FieldOfView(*g.start, FOV_RADIUS)
+	for x := 0; x < g.W; x++ {
+		for y := 0; y < g.H; y++ {
+			if !vis[x][y] {
+				tintRect(g,
+					Rect2D{float64(x) * cw, float64(y) * ch, cw, ch},
+					sdl.Color{R: 0, G: 0, B: 0, A: 160})
+			}
+		}
+	}
+}
+
+// draw the start and end as squares inset in their cells, and a cross over
+// any rejected cell
+func (g *Grid) DrawEndpoints() {
+	sz := 0.6 * g.CellWorldSize()
+	if g.start != nil {
+		drawRect(g,
+			CenteredSquare(g.GridCellSpaceToGridWorldSpace(*g.start), sz),
+			sdl.Color{R: 0, G: 255, B: 0})
+	}
+	if g.end != nil {
+		drawRect(g,
+			CenteredSquare(g.GridCellSpaceToGridWorldSpace(*g.end), sz),
+			sdl.Color{R: 0, G: 255, B: 255})
+	}
+	if g.rejected != nil {
+		c := g.GridCellSpaceToGridWorldSpace(*g.rejected)
+		d := pathfinding.Vec2D{X: sz / 2, Y: sz / 2}
+		e := pathfinding.Vec2D{X: sz / 2, Y: -sz / 2}
+		red := sdl.Color{R: 255, G: 32, B: 32}
+		drawVector(g, c.Sub(d), d.Scale(2), red)
+		drawVector(g, c.Sub(e), e.Scale(2), red)
+	}
+}
+
+// draw the state of the current search over the cells, as chosen by overlay
+func (g *Grid) DrawSearch() {
+	if g.search == nil || !g.showSearch || g.overlay == OVERLAY_NONE {
+		return
+	}
+	cw := g.CellWorldW()
+	ch := g.CellWorldH()
+	expanded := g.search.Result().Expanded
+	for x := 0; x < g.W; x++ {
+		for y := 0; y < g.H; y++ {
+			p := pathfinding.Position{X: x, Y: y}
+			rect := Rect2D{float64(x) * cw, float64(y) * ch, cw, ch}
+			open := g.search.IsOpen(p)
+			closed := g.search.IsClosed(p)
+			if !open && !closed {
+				continue
+			}
+			node := &g.search.Nodes[x][y]
+			switch g.overlay {
+			case OVERLAY_SETS, OVERLAY_COSTS:
+				if open {
+					tintRect(g, rect, sdl.Color{R: 64, G: 128, B: 255, A: 96})
+				} else {
+					tintRect(g, rect, sdl.Color{R: 255, G: 224, B: 64, A: 96})
+				}
+				if g.overlay == OVERLAY_COSTS {
+					g.DrawCosts(rect, node)
+				}
+			case OVERLAY_HEATMAP:
+				if closed {
+					tintRect(g, rect, heatColor(node.Order, expanded))
+				}
+			}
+		}
+	}
+}
+
+// label the cell covering rect with the G, H and F of its node, if the cell
+// is tall enough on screen to fit them
+func (g *Grid) DrawCosts(rect Rect2D, node *pathfinding.Node) {
+	ssr := rect.ToScreenSpaceSdlRect(g)
+	if ssr.H < 3*FONTSZ {
+		return
+	}
+	c := sdl.Color{R: 255, G: 255, B: 255, A: 255}
+	x := int(ssr.X) + 2
+	y := int(ssr.Y)
+	drawText(g, fmt.Sprintf("G %d", node.G), x, y, c)
+	drawText(g, fmt.Sprintf("H %d", node.H), x, y+FONTSZ, c)
+	drawText(g, fmt.Sprintf("F %d", node.F), x, y+2*FONTSZ, c)
+}
+
+// the heatmap color of the i'th of n expansions, from blue (first) to red
+// (last)
+func heatColor(i int, n int) sdl.Color {
+	t := 1.0
+	if n > 1 {
+		t = float64(i-1) / float64(n-1)
+	}
+	return sdl.Color{
+		R: uint8(255 * t),
+		G: 0,
+		B: uint8(255 * (1 - t)),
+		A: 160,
+	}
+}
+
 // draw the path to `st`
 func (g *Grid) DrawPath() {
+	if g.smoothing != SMOOTHING_NONE {
+		for i := 1; i < len(g.smoothed); i++ {
+			drawVector(g,
+				g.smoothed[i-1],
+				g.smoothed[i].Sub(g.smoothed[i-1]),
+				sdl.Color{R: 255, G: 255, B: 255})
+		}
+		return
+	}
 	for _, pp := range g.path {
-		p1 := GridCellSpaceToGridWorldSpace(pp.p1)
-		p2 := GridCellSpaceToGridWorldSpace(pp.p2)
-		drawVector(g.r,
+		p1 := g.GridCellSpaceToGridWorldSpace(pp.p1)
+		p2 := g.GridCellSpaceToGridWorldSpace(pp.p2)
+		drawVector(g,
 			p1,
 			p2.Sub(p1),
 			sdl.Color{R: 255, G: 255, B: 255})
 	}
 }
+
+// draw each agent's remaining path in its color, then the agents, the
+// selected one outlined in white
+func (g *Grid) DrawAgents() {
+	for _, a := range g.agents {
+		from := a.pos
+		for _, cell := range a.path {
+			to := g.GridCellSpaceToGridWorldSpace(cell)
+			drawVector(g, from, to.Sub(from), a.color)
+			from = to
+		}
+	}
+	for _, a := range g.agents {
+		bounds := a.Bounds(g)
+		if a == g.selected {
+			drawRect(g,
+				CenteredSquare(a.pos, bounds.W*1.4),
+				sdl.Color{R: 255, G: 255, B: 255})
+		}
+		drawRect(g, bounds, a.color)
+	}
+}
+
+// the agent at world-space point p, or nil
+func (g *Grid) AgentAt(p pathfinding.Vec2D) *Agent {
+	for _, a := range g.agents {
+		if a.Contains(g, p) {
+			return a
+		}
+	}
+	return nil
+}
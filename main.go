@@ -5,13 +5,10 @@ import (
 	"github.com/veandco/go-sdl2/sdl"
 	"github.com/veandco/go-sdl2/ttf"
 	"log"
-	"math/rand"
 	"os"
-	"time"
 )
 
 func init() {
-	rand.Seed(time.Now().UnixNano())
 	color.NoColor = false
 }
 
@@ -37,9 +34,10 @@ func InitSDL() (*sdl.Renderer, *ttf.Font) {
 
 func main() {
 	var exitcode int
+	cfg := ParseConfig()
 	sdl.Main(func() {
 		r, f := InitSDL()
-		g := NewGame(r, f)
+		g := NewGame(r, f, cfg)
 		exitcode = g.gameloop()
 	})
 	os.Exit(exitcode)
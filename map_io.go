@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"github.com/dt-rush/go-sdl2-pathfinding/pathfinding"
+	"os"
+	"strings"
+)
+
+func isJSON(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".json")
+}
+
+// save the grid to the map file, along with the start and end if it's JSON
+func (g *Game) SaveMap() error {
+	f, err := os.Create(g.mapFile)
+	if err != nil {
+		return err
+	}
+	if isJSON(g.mapFile) {
+		err = pathfinding.SaveGridJSON(f, g.grid.Grid, g.grid.start, g.grid.end)
+	} else {
+		err = pathfinding.SaveGrid(f, g.grid.Grid)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		fmt.Printf("saved %s\n", g.mapFile)
+	}
+	return err
+}
+
+// replace the grid with the one in the map file, keeping the world size.
+// The start and end are placed as saved if the file is JSON
+func (g *Game) LoadMap() error {
+	f, err := os.Open(g.mapFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var loaded *pathfinding.Grid
+	var start, end *pathfinding.Position
+	if isJSON(g.mapFile) {
+		loaded, start, end, err = pathfinding.LoadGridJSON(f)
+	} else {
+		loaded, err = pathfinding.LoadGrid(f)
+	}
+	if err != nil {
+		return err
+	}
+	if g.ticket != nil {
+		g.ticket.Cancel()
+		g.ticket = nil
+	}
+	g.ClearAgents()
+	loaded.WorldW = g.grid.WorldW
+	loaded.WorldH = g.grid.WorldH
+	g.grid.Grid = loaded
+	g.grid.start = nil
+	g.grid.end = nil
+	g.grid.path = g.grid.path[:0]
+	// the search scratch space and the undo history belong to the old grid
+	movement := g.pq.Computer.Movement
+	g.pq = pathfinding.NewPathQueue(loaded, g.pq.Budget)
+	g.pq.Computer.Movement = movement
+	g.agentQ = pathfinding.NewPathQueue(loaded, g.agentQ.Budget)
+	g.agentQ.Computer.Movement = movement
+	g.grid.search = g.pq.Computer
+	g.editor = NewEditor(g.grid)
+	g.mode = MODE_PLACING_START
+	if start != nil {
+		if p, ok := g.EndpointAt(*start); ok {
+			g.grid.SetStart(p)
+			g.mode = MODE_PLACING_END
+		} else {
+			g.Reject(*start)
+		}
+	}
+	if end != nil && g.grid.start != nil {
+		if p, ok := g.EndpointAt(*end); ok {
+			g.grid.SetEnd(p)
+			g.mode = MODE_PLACING_START
+		} else {
+			g.Reject(*end)
+		}
+	}
+	fmt.Printf("loaded %s\n", g.mapFile)
+	g.GridEdited()
+	return nil
+}
@@ -1,15 +1,18 @@
 package main
 
 import (
+	"github.com/dt-rush/go-sdl2-pathfinding/pathfinding"
 	"github.com/veandco/go-sdl2/sdl"
 )
 
-func MouseButtonEventToGridCellPosition(me *sdl.MouseButtonEvent) Position {
-	x, y := ScreenSpaceToGridCellSpace(Vec2D{float64(me.X), float64(me.Y)})
-	return Position{x, y}
+func MouseButtonEventToGridCellPosition(
+	g *Grid, me *sdl.MouseButtonEvent) pathfinding.Position {
+	return g.ScreenSpaceToGridCellSpace(
+		pathfinding.Vec2D{X: float64(me.X), Y: float64(me.Y)})
 }
 
-func MouseMotionEventToGridCellPosition(me *sdl.MouseMotionEvent) Position {
-	x, y := ScreenSpaceToGridCellSpace(Vec2D{float64(me.X), float64(me.Y)})
-	return Position{x, y}
+func MouseMotionEventToGridCellPosition(
+	g *Grid, me *sdl.MouseMotionEvent) pathfinding.Position {
+	return g.ScreenSpaceToGridCellSpace(
+		pathfinding.Vec2D{X: float64(me.X), Y: float64(me.Y)})
 }
@@ -0,0 +1,305 @@
+package pathfinding
+
+import (
+	"fmt"
+	"time"
+)
+
+// Used to associate data with a grid cell during computation
+type Node struct {
+	Pos       Position // position in grid
+	From      *Node    // pointer to next
+	WhichList int      // == N means OPEN, N + 1 means CLOSED
+	G         int      // path cost (weighted by terrain)
+	H         int      // heuristic
+	F         int      // path cost + heuristic
+	HeapIX    int      // index in heap array
+	Order     int      // when CLOSED, the number of expansions up to this one
+}
+
+// Prints in format (k)[x, y]
+func (n *Node) String() string {
+	return fmt.Sprintf("(%d)%v", n.F, n.Pos)
+}
+
+// computes paths over a Grid, keeping per-cell scratch Nodes between calls
+type AStarPathComputer struct {
+	Grid *Grid
+	// the movement model used by queries which don't choose their own. If
+	// nil, EightConnected is used
+	Movement MovementModel
+	// the heuristic used by queries which don't choose their own. If nil,
+	// the movement model's default heuristic is used
+	Heuristic Heuristic
+	// if set, searches using the same movement model between cells in
+	// different components fail at once with ErrNoPath, instead of after
+	// exhausting every cell they can reach. Keep it up to date with the grid
+	Components *Components
+	OH         *NodeHeap
+	N          int
+	Nodes      [][]Node
+	// the heap and scratch Nodes of the backward half of bidirectional
+	// searches, made by the first such search
+	BackOH    *NodeHeap
+	BackNodes [][]Node
+	startNode *Node
+	endNode   *Node
+	search    searchState
+}
+
+// the state of a search in progress, kept between calls to Step
+type searchState struct {
+	end       Position
+	opts      SearchOptions
+	movement  MovementModel
+	heuristic Heuristic
+	minCost   int
+	done      bool
+	res       PathResult
+}
+
+// per-query settings for a search. Zero values fall back to the computer's
+type SearchOptions struct {
+	Movement  MovementModel
+	Heuristic Heuristic
+	// if set, the search never leaves this rectangle of cells
+	Bounds *Rect
+}
+
+func NewAStarPathComputer(grid *Grid) *AStarPathComputer {
+	c := &AStarPathComputer{
+		Grid:  grid,
+		N:     0,
+		Nodes: newNodes(grid),
+		OH:    NewNodeHeap(),
+	}
+	return c
+}
+
+// builds a grid of Nodes, one for each cell of grid
+func newNodes(grid *Grid) [][]Node {
+	// NOTE: in array-speak, the "rows" are columns. It's just nicer to put
+	// X as the first coordinate instead of Y
+	nodes := make([][]Node, grid.W)
+	for x := 0; x < grid.W; x++ {
+		nodes[x] = make([]Node, grid.H)
+		for y := 0; y < grid.H; y++ {
+			nodes[x][y] = Node{Pos: Position{x, y}}
+		}
+	}
+	return nodes
+}
+
+// searches for the cheapest path from start to end
+func (c *AStarPathComputer) AStarPath(start Position, end Position) PathResult {
+	return c.AStarPathWithOptions(start, end, SearchOptions{})
+}
+
+// returns the movement model a search with the given options should use
+func (c *AStarPathComputer) movementFor(opts SearchOptions) MovementModel {
+	if opts.Movement != nil {
+		return opts.Movement
+	}
+	if c.Movement != nil {
+		return c.Movement
+	}
+	return EightConnected
+}
+
+// returns the heuristic a search with the given options should use
+func (c *AStarPathComputer) heuristicFor(opts SearchOptions) Heuristic {
+	if opts.Heuristic != nil {
+		return opts.Heuristic
+	}
+	// a movement model chosen for just this query brings its own heuristic
+	if opts.Movement == nil && c.Heuristic != nil {
+		return c.Heuristic
+	}
+	return c.movementFor(opts).DefaultHeuristic()
+}
+
+// like AStarPath, but with per-query options
+func (c *AStarPathComputer) AStarPathWithOptions(
+	start Position, end Position, opts SearchOptions) PathResult {
+	c.Begin(start, end, opts)
+	c.Step(-1)
+	return c.Result()
+}
+
+// sets up a search from start to end to be run a little at a time by Step,
+// replacing any search in progress. Between steps the search can be
+// inspected through Nodes, IsOpen and IsClosed
+func (c *AStarPathComputer) Begin(
+	start Position, end Position, opts SearchOptions) {
+	t0 := time.Now()
+	c.search = searchState{end: end, opts: opts}
+	s := &c.search
+	defer func() { s.res.Elapsed += time.Since(t0) }()
+	if s.res.Err = checkEndpoints(c.Grid, start, end); s.res.Err != nil {
+		// leave no cell open or closed from the last search
+		c.N += 2
+		s.done = true
+		return
+	}
+	// step costs are weighted by terrain, so scale the heuristic by the
+	// cheapest terrain to keep it from overestimating
+	s.minCost = c.Grid.MinCost()
+	s.movement = c.movementFor(opts)
+	s.heuristic = c.heuristicFor(opts)
+	if c.Components != nil && c.Components.Movement == s.movement &&
+		!c.Components.Reachable(start, end) {
+		// leave no cell open or closed from the last search
+		c.N += 2
+		s.res.Err = ErrNoPath
+		s.done = true
+		return
+	}
+
+	c.begin(start, end, s.minCost*s.heuristic.Estimate(start, end))
+	s.res.Generated++
+}
+
+// runs the search set up by Begin for at most maxExpansions node expansions
+// (no limit if negative), returning true once it has finished
+func (c *AStarPathComputer) Step(maxExpansions int) bool {
+	s := &c.search
+	if s.done {
+		return true
+	}
+	t0 := time.Now()
+	defer func() { s.res.Elapsed += time.Since(t0) }()
+
+	// while open heap has elements...
+	for n := 0; maxExpansions < 0 || n < maxExpansions; n++ {
+		// pop from open heap and set as closed (whichlist == c.N + 1)
+		cur, err := c.OH.Pop()
+		// if err, we have exhausted all squares on the open heap and found
+		// no path
+		if err != nil {
+			s.res.Err = ErrNoPath
+			s.done = true
+			return true
+		}
+		s.res.Expanded++
+		// set popped node to CLOSED
+		cur.WhichList = c.N + 1
+		cur.Order = s.res.Expanded
+		// if the current cell is the end, we're here. build the result
+		if cur == c.endNode {
+			s.res.Path = tracePath(cur)
+			s.res.Cost = cur.G
+			s.done = true
+			return true
+		}
+		// else, we have yet to complete the path. So:
+		// for each neighbor
+		for _, m := range s.movement.Moves() {
+			nbrPos, dist, err := c.Grid.NbrOf(cur.Pos, m, s.movement)
+			if err != nil ||
+				(s.opts.Bounds != nil && !s.opts.Bounds.Contains(nbrPos)) {
+				continue
+			}
+			nbr := &c.Nodes[nbrPos.X][nbrPos.Y]
+			// compute g, h for the neighbor
+			g := cur.G + dist
+			h := s.minCost * s.heuristic.Estimate(nbr.Pos, s.end)
+			if c.consider(c.OH, cur, nbr, g, h) {
+				s.res.Generated++
+			}
+		}
+	}
+	return false
+}
+
+// whether the search set up by Begin has finished
+func (c *AStarPathComputer) Done() bool {
+	return c.search.done
+}
+
+// the result of the search set up by Begin. Only final once Done; before
+// that it holds the counts and time spent so far
+func (c *AStarPathComputer) Result() PathResult {
+	return c.search.res
+}
+
+// abandons the search in progress, whose result becomes ErrCancelled
+func (c *AStarPathComputer) Cancel() {
+	if c.search.done {
+		return
+	}
+	c.search.done = true
+	c.search.res.Err = ErrCancelled
+}
+
+// tests if the cell at p is on the open heap of the current search
+func (c *AStarPathComputer) IsOpen(p Position) bool {
+	return c.Nodes[p.X][p.Y].WhichList == c.N
+}
+
+// tests if the cell at p has been expanded by the current search
+func (c *AStarPathComputer) IsClosed(p Position) bool {
+	return c.Nodes[p.X][p.Y].WhichList == c.N+1
+}
+
+// resets the computer for a new search from start to end, with h the
+// heuristic value of the start node
+func (c *AStarPathComputer) begin(start Position, end Position, h int) {
+	// clear the heap which contains leftover nodes from the last calculation
+	c.OH.Clear()
+	// increment N so WhichList works properly
+	c.N += 2
+	// set start and end nodes
+	c.startNode = &c.Nodes[start.X][start.Y]
+	c.endNode = &c.Nodes[end.X][end.Y]
+	// add first node to OPEN heap
+	*c.startNode = Node{
+		Pos:       c.startNode.Pos,
+		From:      nil,
+		WhichList: c.N,
+		G:         0,
+		H:         h,
+	}
+	c.OH.Add(c.startNode)
+}
+
+// offers nbr a path through cur with path cost g and heuristic h, updating
+// the open heap oh. Returns true if nbr was newly pushed to oh
+func (c *AStarPathComputer) consider(
+	oh *NodeHeap, cur *Node, nbr *Node, g int, h int) bool {
+	// don't consider this neighbor if the neighbor is in the closed
+	// list *and* our g is greater or equal to its g score (we already
+	// have a better way to get to it)
+	isClosed := nbr.WhichList == c.N+1
+	if isClosed && g >= nbr.G {
+		return false
+	}
+	// if not on open heap, add it with "From" == cur
+	isOpen := nbr.WhichList == c.N
+	if !isOpen {
+		// set From, G, H
+		nbr.From = cur
+		nbr.G = g
+		nbr.H = h
+		// set whichlist == OPEN
+		nbr.WhichList = c.N
+		// push to open heap
+		oh.Add(nbr)
+		return true
+	}
+	// if it *is* on the open heap already, check to see if
+	// this is a better path to that square
+	// on -> "open node"
+	gAlready := nbr.G
+	if g < gAlready {
+		// if the open node could be reached better by
+		// this path, set the g to the new lower g, set the
+		// "From" reference to cur and fix up the heap because
+		// we've changed the value of one of its elements
+		nbr.From = cur
+		// compute new F after setting new G and add to OPEN heap
+		nbr.G = g
+		nbr.F = nbr.G + nbr.H
+		oh.Modified(nbr)
+	}
+	return false
+}
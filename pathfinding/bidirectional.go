@@ -0,0 +1,152 @@
+package pathfinding
+
+import (
+	"time"
+)
+
+// Bidirectional A*: an A* forward from start over Nodes and another
+// backward from end over BackNodes, each expanding in turn whichever has
+// fewer open nodes. Every cell reached by both gives a path, and the
+// cheapest found so far, of cost mu, is returned once the lowest F on
+// either heap is at least mu: with an admissible heuristic, no path yet
+// to be found can be cheaper. Stopping when the searches first meet
+// instead could miss the best path. Paths cost the same as AStarPath's,
+// and Path runs from start to end as usual
+func (c *AStarPathComputer) BidirectionalAStarPath(
+	start Position, end Position) PathResult {
+	return c.BidirectionalAStarPathWithOptions(start, end, SearchOptions{})
+}
+
+// like BidirectionalAStarPath, but with per-query options
+func (c *AStarPathComputer) BidirectionalAStarPathWithOptions(
+	start Position, end Position, opts SearchOptions) (res PathResult) {
+	t0 := time.Now()
+	defer func() { res.Elapsed = time.Since(t0) }()
+	if res.Err = checkEndpoints(c.Grid, start, end); res.Err != nil {
+		return res
+	}
+	minCost := c.Grid.MinCost()
+	movement := c.movementFor(opts)
+	heuristic := c.heuristicFor(opts)
+	if c.BackNodes == nil {
+		c.BackNodes = newNodes(c.Grid)
+		c.BackOH = NewNodeHeap()
+	}
+	// as in AStarPath, only the start may lie outside the bounds
+	inBounds := func(p Position) bool {
+		return opts.Bounds == nil || opts.Bounds.Contains(p) || p == start
+	}
+	if !inBounds(end) ||
+		(c.Components != nil && c.Components.Movement == movement &&
+			!c.Components.Reachable(start, end)) {
+		// leave no cell open or closed from the last search
+		c.N += 2
+		res.Err = ErrNoPath
+		return res
+	}
+
+	h := minCost * heuristic.Estimate(start, end)
+	c.begin(start, end, h)
+	// the backward search uses the generation begin set for the forward
+	c.BackOH.Clear()
+	goalNode := &c.BackNodes[end.X][end.Y]
+	*goalNode = Node{
+		Pos:       end,
+		WhichList: c.N,
+		H:         h,
+	}
+	c.BackOH.Add(goalNode)
+	res.Generated += 2
+
+	// the cheapest path found so far, and the cell the searches met at on it
+	mu := -1
+	meet := start
+	if start == end {
+		mu = 0
+	}
+	// notes a path through p if both searches have reached it
+	offer := func(p Position) {
+		fwd := &c.Nodes[p.X][p.Y]
+		bwd := &c.BackNodes[p.X][p.Y]
+		if !c.reached(fwd) || !c.reached(bwd) {
+			return
+		}
+		if cost := fwd.G + bwd.G; mu < 0 || cost < mu {
+			mu = cost
+			meet = p
+		}
+	}
+
+	for {
+		fwdTop, err := c.OH.Peek()
+		if err != nil {
+			break
+		}
+		bwdTop, err := c.BackOH.Peek()
+		if err != nil {
+			break
+		}
+		if mu >= 0 && (fwdTop.F >= mu || bwdTop.F >= mu) {
+			break
+		}
+		res.Expanded++
+		if c.OH.Len() <= c.BackOH.Len() {
+			// expand forward: the cost of a step is that of the cell entered
+			cur, _ := c.OH.Pop()
+			cur.WhichList = c.N + 1
+			cur.Order = res.Expanded
+			for _, m := range movement.Moves() {
+				nbrPos, dist, err := c.Grid.NbrOf(cur.Pos, m, movement)
+				if err != nil || !inBounds(nbrPos) {
+					continue
+				}
+				nbr := &c.Nodes[nbrPos.X][nbrPos.Y]
+				g := cur.G + dist
+				h := minCost * heuristic.Estimate(nbrPos, end)
+				if c.consider(c.OH, cur, nbr, g, h) {
+					res.Generated++
+				}
+				offer(nbrPos)
+			}
+		} else {
+			// expand backward, over the moves which lead into cur, each
+			// costing as much as entering cur
+			cur, _ := c.BackOH.Pop()
+			cur.WhichList = c.N + 1
+			cur.Order = res.Expanded
+			for _, m := range movement.Moves() {
+				p := Position{cur.Pos.X - m.DX, cur.Pos.Y - m.DY}
+				if !c.Grid.InGrid(p) || c.Grid.IsObstacle(p) ||
+					!movement.CanMove(c.Grid, p, m) || !inBounds(p) {
+					continue
+				}
+				nbr := &c.BackNodes[p.X][p.Y]
+				g := cur.G + m.Cost*c.Grid.CostOf(cur.Pos)
+				h := minCost * heuristic.Estimate(start, p)
+				if c.consider(c.BackOH, cur, nbr, g, h) {
+					res.Generated++
+				}
+				offer(p)
+			}
+		}
+	}
+	if mu < 0 {
+		res.Err = ErrNoPath
+		return res
+	}
+	// the forward search's path to meet, then the backward search's on
+	// from it to end
+	res.Path = tracePath(&c.Nodes[meet.X][meet.Y])
+	bwd := &c.BackNodes[meet.X][meet.Y]
+	for cur := bwd.From; cur != nil; cur = cur.From {
+		res.Path = append(res.Path, cur.Pos)
+	}
+	res.Cost = c.Nodes[meet.X][meet.Y].G + bwd.G
+	return res
+}
+
+// tests if n, in Nodes or BackNodes, is open or closed in the current
+// search
+func (c *AStarPathComputer) reached(n *Node) bool {
+	return n.WhichList == c.N || n.WhichList == c.N+1
+}
@@ -0,0 +1,93 @@
+package pathfinding
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// a grid of randomly mixed terrain, weighted and impassable
+func mixedGrid(rng *rand.Rand, w int, h int) *Grid {
+	kinds := []int{EMPTY, EMPTY, EMPTY, OBSTACLE, ROAD, FOREST, MUD, WATER}
+	g := NewGrid(w, h)
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			g.Cells[x][y] = kinds[rng.Intn(len(kinds))]
+		}
+	}
+	return g
+}
+
+// stopping only once neither heap's lowest F beats the best meeting cost
+// must give A*'s (optimal) cost, under every movement model
+func TestBidirectionalMatchesAStar(t *testing.T) {
+	const w, h = 40, 30
+	rng := rand.New(rand.NewSource(3))
+	models := []MovementModel{
+		EightConnected, EightConnectedCutting, FourConnected, SixteenConnected,
+	}
+	for _, model := range models {
+		g := mixedGrid(rng, w, h)
+		c := NewAStarPathComputer(g)
+		bidir := NewAStarPathComputer(g)
+		opts := SearchOptions{Movement: model}
+		for i := 0; i < 200; i++ {
+			start := Position{rng.Intn(w), rng.Intn(h)}
+			end := Position{rng.Intn(w), rng.Intn(h)}
+			a := c.AStarPathWithOptions(start, end, opts)
+			b := bidir.BidirectionalAStarPathWithOptions(start, end, opts)
+			if a.Err != b.Err || a.Cost != b.Cost {
+				t.Errorf("%s, %v to %v: A* cost %d (%v), bidirectional "+
+					"cost %d (%v)", model.(*GridMovement).Name, start, end,
+					a.Cost, a.Err, b.Cost, b.Err)
+				continue
+			}
+			if b.Err == nil && pathCostUnder(t, g, model, b.Path) != b.Cost {
+				t.Errorf("%v to %v: path %v doesn't cost %d",
+					start, end, b.Path, b.Cost)
+			}
+		}
+	}
+}
+
+func TestBidirectionalEdgeCases(t *testing.T) {
+	g := NewGrid(10, 10)
+	g.Cells[4][4] = MUD
+	for y := 0; y < 10; y++ {
+		g.Cells[6][y] = OBSTACLE
+	}
+	c := NewAStarPathComputer(g)
+	res := c.BidirectionalAStarPath(Position{4, 4}, Position{4, 4})
+	if res.Err != nil || res.Cost != 0 || len(res.Path) != 1 {
+		t.Errorf("start == end: got path %v, cost %d, err %v",
+			res.Path, res.Cost, res.Err)
+	}
+	res = c.BidirectionalAStarPath(Position{1, 1}, Position{8, 8})
+	if res.Err != ErrNoPath || res.Path != nil {
+		t.Errorf("across the wall: got path %v, err %v", res.Path, res.Err)
+	}
+	res = c.BidirectionalAStarPath(Position{1, 1}, Position{6, 1})
+	if res.Err != ErrEndBlocked {
+		t.Errorf("onto the wall: got err %v", res.Err)
+	}
+}
+
+// the cost of walking path under model, failing t if a step isn't a move
+func pathCostUnder(t *testing.T, g *Grid, model MovementModel,
+	path []Position) int {
+	cost := 0
+	for i := 1; i < len(path); i++ {
+		step := -1
+		for _, m := range model.Moves() {
+			if path[i-1].X+m.DX == path[i].X && path[i-1].Y+m.DY == path[i].Y {
+				if _, d, err := g.NbrOf(path[i-1], m, model); err == nil {
+					step = d
+				}
+			}
+		}
+		if step < 0 {
+			t.Fatalf("%v to %v is not a move", path[i-1], path[i])
+		}
+		cost += step
+	}
+	return cost
+}
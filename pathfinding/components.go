@@ -0,0 +1,124 @@
+package pathfinding
+
+import (
+	"math/rand"
+)
+
+// the label of impassable cells, which belong to no component
+const NO_COMPONENT = -1
+
+// labels the connected components of a Grid under a movement model: two
+// cells have the same label exactly when some path joins them. The moves of
+// the grid movement models can all be made in reverse, so being connected
+// works both ways. Labels go stale when cells change; call Relabel after
+// changing them
+type Components struct {
+	Grid     *Grid
+	Movement MovementModel
+	// Labels[x][y] is the component of the cell at x, y, or NO_COMPONENT
+	Labels [][]int
+	// the number of cells in each component, indexed by label
+	Sizes []int
+}
+
+func NewComponents(grid *Grid, model MovementModel) *Components {
+	c := &Components{
+		Grid:     grid,
+		Movement: model,
+		Labels:   make([][]int, grid.W),
+	}
+	for x := 0; x < grid.W; x++ {
+		c.Labels[x] = make([]int, grid.H)
+	}
+	c.Relabel()
+	return c
+}
+
+// recomputes the labels from the grid's cells, flood-filling each
+// component in turn
+func (c *Components) Relabel() {
+	for x := 0; x < c.Grid.W; x++ {
+		for y := 0; y < c.Grid.H; y++ {
+			c.Labels[x][y] = NO_COMPONENT
+		}
+	}
+	c.Sizes = c.Sizes[:0]
+	var stack []Position
+	for x := 0; x < c.Grid.W; x++ {
+		for y := 0; y < c.Grid.H; y++ {
+			p := Position{x, y}
+			if c.Labels[x][y] != NO_COMPONENT || c.Grid.IsObstacle(p) {
+				continue
+			}
+			label := len(c.Sizes)
+			size := 0
+			c.Labels[x][y] = label
+			stack = append(stack[:0], p)
+			for len(stack) > 0 {
+				cur := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				size++
+				for _, m := range c.Movement.Moves() {
+					nbr, _, err := c.Grid.NbrOf(cur, m, c.Movement)
+					if err != nil || c.Labels[nbr.X][nbr.Y] != NO_COMPONENT {
+						continue
+					}
+					c.Labels[nbr.X][nbr.Y] = label
+					stack = append(stack, nbr)
+				}
+			}
+			c.Sizes = append(c.Sizes, size)
+		}
+	}
+}
+
+// the number of components
+func (c *Components) Count() int {
+	return len(c.Sizes)
+}
+
+// the label of the component with the most cells, or NO_COMPONENT if every
+// cell is impassable
+func (c *Components) Largest() int {
+	largest := NO_COMPONENT
+	for label, size := range c.Sizes {
+		if largest == NO_COMPONENT || size > c.Sizes[largest] {
+			largest = label
+		}
+	}
+	return largest
+}
+
+// tests if a path joins a and b, both of which must be in the grid
+func (c *Components) Reachable(a Position, b Position) bool {
+	la := c.Labels[a.X][a.Y]
+	return la != NO_COMPONENT && la == c.Labels[b.X][b.Y]
+}
+
+// wraps a generator so that its terrain is always one connected region
+// under Movement: every passable cell outside the largest component is
+// turned into an obstacle
+type ConnectedGenerator struct {
+	Generator TerrainGenerator
+	Movement  MovementModel
+}
+
+func (g *ConnectedGenerator) Name() string {
+	return g.Generator.Name() + "-connected"
+}
+
+func (g *ConnectedGenerator) Generate(w int, h int, rng *rand.Rand) [][]int {
+	grid := NewGrid(w, h)
+	grid.Cells = g.Generator.Generate(w, h, rng)
+	comps := NewComponents(grid, g.Movement)
+	largest := comps.Largest()
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			label := comps.Labels[x][y]
+			if label != NO_COMPONENT && label != largest {
+				grid.Cells[x][y] = OBSTACLE
+			}
+		}
+	}
+	return grid.Cells
+}
@@ -0,0 +1,39 @@
+package pathfinding
+
+// world-space width of a single grid cell
+func (g *Grid) CellWorldW() float64 {
+	return g.WorldW / float64(g.W)
+}
+
+// world-space height of a single grid cell
+func (g *Grid) CellWorldH() float64 {
+	return g.WorldH / float64(g.H)
+}
+
+// returns the world-space point at the center of the cell p
+func (g *Grid) GridCellSpaceToGridWorldSpace(p Position) Vec2D {
+	cw := g.CellWorldW()
+	ch := g.CellWorldH()
+	return Vec2D{
+		float64(p.X)*cw + cw/2,
+		float64(p.Y)*ch + ch/2}
+}
+
+// returns the cell containing the world-space point p, clamped to the grid
+func (g *Grid) GridWorldSpaceToGridCellSpace(p Vec2D) Position {
+	x := int(p.X / g.CellWorldW())
+	y := int(p.Y / g.CellWorldH())
+	if x > g.W-1 {
+		x = g.W - 1
+	}
+	if x < 0 {
+		x = 0
+	}
+	if y > g.H-1 {
+		y = g.H - 1
+	}
+	if y < 0 {
+		y = 0
+	}
+	return Position{x, y}
+}
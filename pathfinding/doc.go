@@ -0,0 +1,4 @@
+// Package pathfinding holds the headless core of the pathfinding demo: the
+// grid model, the node heap and the A* search over them. It does not depend
+// on SDL, so it can be used from servers, bots, tools and tests.
+package pathfinding
@@ -0,0 +1,307 @@
+package pathfinding
+
+import (
+	"container/heap"
+	"math"
+	"time"
+)
+
+// the g / rhs value of cells with no known path to the goal
+const dsInf = math.MaxInt32
+
+// D* Lite: an incremental planner which searches backward from the goal and
+// keeps its state between calls. After cells change (a door opens, a wall
+// is built) only the part of the search the change affects is repaired,
+// and after the start moves along the path nothing has to be redone at all.
+// Plan as often as needed toward the same goal; planning toward a new goal
+// starts over
+type DStarLitePlanner struct {
+	Grid *Grid
+	// the movement model to plan with. If nil, EightConnected is used
+	Movement MovementModel
+	// the heuristic to plan with. If nil, the movement model's default is
+	// used
+	Heuristic Heuristic
+	goal      Position
+	// the start at the time of the last key adjustment, and the total
+	// heuristic distance the start has moved since planning began
+	last        Position
+	km          int
+	g           [][]int
+	rhs         [][]int
+	q           dsQueue
+	initialized bool
+	// scratch counters for the current call's PathResult
+	expanded  int
+	generated int
+}
+
+func NewDStarLitePlanner(grid *Grid) *DStarLitePlanner {
+	p := &DStarLitePlanner{
+		Grid: grid,
+		g:    make([][]int, grid.W),
+		rhs:  make([][]int, grid.W),
+	}
+	p.q.ix = make([][]int, grid.W)
+	for x := 0; x < grid.W; x++ {
+		p.g[x] = make([]int, grid.H)
+		p.rhs[x] = make([]int, grid.H)
+		p.q.ix[x] = make([]int, grid.H)
+	}
+	return p
+}
+
+func (p *DStarLitePlanner) movement() MovementModel {
+	if p.Movement != nil {
+		return p.Movement
+	}
+	return EightConnected
+}
+
+func (p *DStarLitePlanner) h(a Position, b Position) int {
+	heuristic := p.Heuristic
+	if heuristic == nil {
+		heuristic = p.movement().DefaultHeuristic()
+	}
+	return p.Grid.MinCost() * heuristic.Estimate(a, b)
+}
+
+// forget all previous planning and start a search toward goal
+func (p *DStarLitePlanner) reset(start Position, goal Position) {
+	for x := 0; x < p.Grid.W; x++ {
+		for y := 0; y < p.Grid.H; y++ {
+			p.g[x][y] = dsInf
+			p.rhs[x][y] = dsInf
+			p.q.ix[x][y] = -1
+		}
+	}
+	p.q.cells = p.q.cells[:0]
+	p.q.keys = p.q.keys[:0]
+	p.goal = goal
+	p.last = start
+	p.km = 0
+	p.rhs[goal.X][goal.Y] = 0
+	p.q.push(goal, p.key(start, goal))
+	p.generated++
+	p.initialized = true
+}
+
+// the priority of cell s, given the agent is at start
+func (p *DStarLitePlanner) key(start Position, s Position) dsKey {
+	m := p.g[s.X][s.Y]
+	if p.rhs[s.X][s.Y] < m {
+		m = p.rhs[s.X][s.Y]
+	}
+	if m == dsInf {
+		return dsKey{dsInf, dsInf}
+	}
+	return dsKey{m + p.h(start, s) + p.km, m}
+}
+
+// recompute the rhs of s (its cost to the goal through its best successor)
+// and queue it if that makes it inconsistent
+func (p *DStarLitePlanner) updateVertex(start Position, s Position) {
+	if s != p.goal {
+		best := dsInf
+		if !p.Grid.IsObstacle(s) {
+			movement := p.movement()
+			for _, m := range movement.Moves() {
+				nbr, cost, err := p.Grid.NbrOf(s, m, movement)
+				if err != nil || p.g[nbr.X][nbr.Y] == dsInf {
+					continue
+				}
+				if c := cost + p.g[nbr.X][nbr.Y]; c < best {
+					best = c
+				}
+			}
+		}
+		p.rhs[s.X][s.Y] = best
+	}
+	p.q.remove(s)
+	if p.g[s.X][s.Y] != p.rhs[s.X][s.Y] {
+		p.q.push(s, p.key(start, s))
+		p.generated++
+	}
+}
+
+// update every cell which may be a predecessor of s (has a move into s)
+func (p *DStarLitePlanner) updatePredecessors(start Position, s Position) {
+	for _, m := range p.movement().Moves() {
+		pred := Position{s.X - m.DX, s.Y - m.DY}
+		if p.Grid.InGrid(pred) {
+			p.updateVertex(start, pred)
+		}
+	}
+}
+
+func (p *DStarLitePlanner) computeShortestPath(start Position) {
+	for p.q.Len() > 0 &&
+		(p.q.topKey().less(p.key(start, start)) ||
+			p.rhs[start.X][start.Y] != p.g[start.X][start.Y]) {
+		u := p.q.cells[0]
+		kold := p.q.keys[0]
+		knew := p.key(start, u)
+		p.expanded++
+		switch {
+		case kold.less(knew):
+			// the key was computed before the start moved on
+			p.q.remove(u)
+			p.q.push(u, knew)
+		case p.g[u.X][u.Y] > p.rhs[u.X][u.Y]:
+			// overconsistent: the cell got cheaper, settle it
+			p.g[u.X][u.Y] = p.rhs[u.X][u.Y]
+			p.q.remove(u)
+			p.updatePredecessors(start, u)
+		default:
+			// underconsistent: the cell got dearer, raise it and let its
+			// predecessors find a better way
+			p.g[u.X][u.Y] = dsInf
+			p.updateVertex(start, u)
+			p.updatePredecessors(start, u)
+		}
+	}
+}
+
+// Tell the planner that the cells at the given positions have changed in
+// Grid.Cells. The repair is done by the next call to Plan
+func (p *DStarLitePlanner) UpdateCells(changed []Position) {
+	if !p.initialized {
+		return
+	}
+	// a changed cell alters the moves into and out of it and those which
+	// brush past it, all of which start within 2 cells of it
+	seen := make(map[Position]bool)
+	for _, c := range changed {
+		for dx := -2; dx <= 2; dx++ {
+			for dy := -2; dy <= 2; dy++ {
+				s := Position{c.X + dx, c.Y + dy}
+				if !seen[s] && p.Grid.InGrid(s) {
+					seen[s] = true
+					p.updateVertex(p.last, s)
+				}
+			}
+		}
+	}
+}
+
+// plans a path from start to goal, reusing the previous search if the goal
+// is the same as last time
+func (p *DStarLitePlanner) Plan(start Position, goal Position) (res PathResult) {
+	t0 := time.Now()
+	defer func() { res.Elapsed = time.Since(t0) }()
+	if res.Err = checkEndpoints(p.Grid, start, goal); res.Err != nil {
+		return res
+	}
+	p.expanded = 0
+	p.generated = 0
+	if !p.initialized || goal != p.goal {
+		p.reset(start, goal)
+	} else if start != p.last {
+		// keys already queued stay valid lower bounds if km grows by as
+		// much as the heuristic to any cell can have shrunk
+		p.km += p.h(p.last, start)
+		p.last = start
+	}
+	p.computeShortestPath(start)
+	res.Expanded = p.expanded
+	res.Generated = p.generated
+	if p.g[start.X][start.Y] == dsInf {
+		res.Err = ErrNoPath
+		return res
+	}
+	// walk down the g values from start to the goal
+	movement := p.movement()
+	res.Path = []Position{start}
+	for cur := start; cur != goal; {
+		best := dsInf
+		var next Position
+		var nextCost int
+		for _, m := range movement.Moves() {
+			nbr, cost, err := p.Grid.NbrOf(cur, m, movement)
+			if err != nil || p.g[nbr.X][nbr.Y] == dsInf {
+				continue
+			}
+			if c := cost + p.g[nbr.X][nbr.Y]; c < best {
+				best = c
+				next = nbr
+				nextCost = cost
+			}
+		}
+		if best == dsInf || len(res.Path) > p.Grid.W*p.Grid.H {
+			res.Path = nil
+			res.Cost = 0
+			res.Err = ErrNoPath
+			return res
+		}
+		res.Path = append(res.Path, next)
+		res.Cost += nextCost
+		cur = next
+	}
+	return res
+}
+
+// D* Lite priority: compared on k1, then k2
+type dsKey struct {
+	k1 int
+	k2 int
+}
+
+func (a dsKey) less(b dsKey) bool {
+	return a.k1 < b.k1 || (a.k1 == b.k1 && a.k2 < b.k2)
+}
+
+// the D* Lite priority queue of cells, a container/heap with each cell's
+// heap index kept in ix (-1 when not queued)
+type dsQueue struct {
+	cells []Position
+	keys  []dsKey
+	ix    [][]int
+}
+
+func (q *dsQueue) Len() int { return len(q.cells) }
+
+func (q *dsQueue) Less(i int, j int) bool { return q.keys[i].less(q.keys[j]) }
+
+func (q *dsQueue) Swap(i int, j int) {
+	q.cells[i], q.cells[j] = q.cells[j], q.cells[i]
+	q.keys[i], q.keys[j] = q.keys[j], q.keys[i]
+	q.ix[q.cells[i].X][q.cells[i].Y] = i
+	q.ix[q.cells[j].X][q.cells[j].Y] = j
+}
+
+// Push and Pop are for container/heap only; use push and remove
+func (q *dsQueue) Push(x interface{}) {
+	e := x.(dsEntry)
+	q.ix[e.pos.X][e.pos.Y] = len(q.cells)
+	q.cells = append(q.cells, e.pos)
+	q.keys = append(q.keys, e.key)
+}
+
+func (q *dsQueue) Pop() interface{} {
+	last := len(q.cells) - 1
+	e := dsEntry{q.cells[last], q.keys[last]}
+	q.ix[e.pos.X][e.pos.Y] = -1
+	q.cells = q.cells[:last]
+	q.keys = q.keys[:last]
+	return e
+}
+
+type dsEntry struct {
+	pos Position
+	key dsKey
+}
+
+func (q *dsQueue) push(p Position, k dsKey) {
+	heap.Push(q, dsEntry{p, k})
+}
+
+// removes p from the queue if it's there
+func (q *dsQueue) remove(p Position) {
+	if i := q.ix[p.X][p.Y]; i >= 0 {
+		heap.Remove(q, i)
+	}
+}
+
+func (q *dsQueue) topKey() dsKey {
+	return q.keys[0]
+}
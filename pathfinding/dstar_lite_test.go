@@ -0,0 +1,57 @@
+package pathfinding
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// replanning after cells change and the start moves along the path must
+// give the cost a fresh A* search gives, under every movement model
+func TestDStarLiteReplansLikeAStar(t *testing.T) {
+	const w, h = 30, 20
+	rng := rand.New(rand.NewSource(9))
+	models := []MovementModel{
+		EightConnected, EightConnectedCutting, FourConnected, SixteenConnected,
+	}
+	kinds := []int{EMPTY, OBSTACLE, OBSTACLE, ROAD, MUD, WATER}
+	for _, model := range models {
+		name := model.(*GridMovement).Name
+		for run := 0; run < 20; run++ {
+			g := mixedGrid(rng, w, h)
+			p := NewDStarLitePlanner(g)
+			p.Movement = model
+			c := NewAStarPathComputer(g)
+			opts := SearchOptions{Movement: model}
+			start := Position{rng.Intn(w), rng.Intn(h)}
+			goal := Position{rng.Intn(w), rng.Intn(h)}
+			for step := 0; step < 10; step++ {
+				a := c.AStarPathWithOptions(start, goal, opts)
+				d := p.Plan(start, goal)
+				if a.Err != d.Err || a.Cost != d.Cost {
+					t.Fatalf("%s, run %d, step %d, %v to %v: A* cost %d "+
+						"(%v), D* Lite cost %d (%v)", name, run, step,
+						start, goal, a.Cost, a.Err, d.Cost, d.Err)
+				}
+				if d.Err == nil &&
+					pathCostUnder(t, g, model, d.Path) != d.Cost {
+					t.Fatalf("%s, %v to %v: path %v doesn't cost %d",
+						name, start, goal, d.Path, d.Cost)
+				}
+				// move the start a little way along the path
+				if len(d.Path) > 2 {
+					start = d.Path[1+rng.Intn(2)]
+				}
+				var changed []Position
+				for i := 0; i < 5; i++ {
+					q := Position{rng.Intn(w), rng.Intn(h)}
+					if q == start || q == goal {
+						continue
+					}
+					g.Cells[q.X][q.Y] = kinds[rng.Intn(len(kinds))]
+					changed = append(changed, q)
+				}
+				p.UpdateCells(changed)
+			}
+		}
+	}
+}
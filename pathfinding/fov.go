@@ -0,0 +1,131 @@
+package pathfinding
+
+// the cells visible from origin, as a bitmap indexed like Cells, seeing
+// out to radius cells (measured in straight lines from center to center),
+// or as far as the grid goes if radius is negative. OBSTACLE cells are
+// opaque and everything else, including other impassable terrain, is seen
+// through; the edge of the grid is a wall. Opaque cells are visible
+// themselves, so walls show.
+//
+// This is symmetric recursive shadowcasting: each quadrant around origin
+// is scanned a row at a time outwards, narrowing the slopes still lit as
+// walls cast shadows, and an open cell is visible only if its center is
+// lit, so that if a can see b, b can see a. Walls cast their shadows from
+// the middle of their row, so a line of sight may clip a wall's corner
+// (every cell LineOfSight can see is visible, but not the other way round)
+func (g *Grid) FieldOfView(origin Position, radius int) [][]bool {
+	vis := make([][]bool, g.W)
+	for x := 0; x < g.W; x++ {
+		vis[x] = make([]bool, g.H)
+	}
+	if !g.InGrid(origin) {
+		return vis
+	}
+	vis[origin.X][origin.Y] = true
+	maxDepth := radius
+	if maxDepth < 0 {
+		maxDepth = g.W + g.H
+	}
+	for q := 0; q < 4; q++ {
+		s := &shadowcaster{
+			g:        g,
+			vis:      vis,
+			origin:   origin,
+			quadrant: q,
+			radius:   radius,
+			maxDepth: maxDepth,
+		}
+		s.scan(1, slope{-1, 1}, slope{1, 1})
+	}
+	return vis
+}
+
+// a slope of a line out from the origin, num columns across for every
+// den rows out, den > 0
+type slope struct {
+	num int
+	den int
+}
+
+// the state of a scan of one quadrant. Quadrants 0-3 face -y, +y, +x and
+// -x; a cell in one is given by its depth, the rows out from the origin,
+// and its column across the quadrant
+type shadowcaster struct {
+	g        *Grid
+	vis      [][]bool
+	origin   Position
+	quadrant int
+	radius   int
+	maxDepth int
+}
+
+// the cell at depth and col in the quadrant
+func (s *shadowcaster) cell(depth int, col int) Position {
+	switch s.quadrant {
+	case 0:
+		return Position{s.origin.X + col, s.origin.Y - depth}
+	case 1:
+		return Position{s.origin.X + col, s.origin.Y + depth}
+	case 2:
+		return Position{s.origin.X + depth, s.origin.Y + col}
+	default:
+		return Position{s.origin.X - depth, s.origin.Y + col}
+	}
+}
+
+func (s *shadowcaster) opaque(p Position) bool {
+	return !s.g.InGrid(p) || s.g.Cells[p.X][p.Y] == OBSTACLE
+}
+
+// scans the cells at depth between the slopes start and end, and the rows
+// beyond them left lit
+func (s *shadowcaster) scan(depth int, start slope, end slope) {
+	if depth > s.maxDepth {
+		return
+	}
+	// the columns whose cells the slopes reach, rounding ties outwards so
+	// that a wall half in the light is scanned
+	minCol := floorDiv(2*depth*start.num+start.den, 2*start.den)
+	maxCol := -floorDiv(-2*depth*end.num+end.den, 2*end.den)
+	prevOpaque := false
+	for col := minCol; col <= maxCol; col++ {
+		p := s.cell(depth, col)
+		opaque := s.opaque(p)
+		// a wall is seen if any of it is lit, anything else only if its
+		// center is
+		lit := opaque ||
+			(col*start.den >= depth*start.num &&
+				col*end.den <= depth*end.num)
+		if lit && s.g.InGrid(p) && s.inRadius(depth, col) {
+			s.vis[p.X][p.Y] = true
+		}
+		if col > minCol {
+			// the near edge of a wall after an open cell, or of an open
+			// cell after a wall
+			edge := slope{2*col - 1, 2 * depth}
+			if prevOpaque && !opaque {
+				start = edge
+			}
+			if !prevOpaque && opaque {
+				s.scan(depth+1, start, edge)
+			}
+		}
+		prevOpaque = opaque
+	}
+	if minCol <= maxCol && !prevOpaque {
+		s.scan(depth+1, start, end)
+	}
+}
+
+func (s *shadowcaster) inRadius(depth int, col int) bool {
+	return s.radius < 0 || depth*depth+col*col <= s.radius*s.radius
+}
+
+// a / b rounded down, b > 0
+func floorDiv(a int, b int) int {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,366 @@
+package pathfinding
+
+import (
+	"math"
+	"math/rand"
+)
+
+// fills grids with procedurally generated terrain. Generators take all
+// their randomness from the given source, so the same seed always gives the
+// same terrain
+type TerrainGenerator interface {
+	Name() string
+	// returns w x h cells of terrain kinds, indexed [x][y]
+	Generate(w int, h int, rng *rand.Rand) [][]int
+}
+
+// the generators with their default settings, in the order the demo cycles
+// through them
+var Generators = []TerrainGenerator{
+	&BlobGenerator{Density: 0.05},
+	&NoiseGenerator{Density: 0.3},
+	&CaveGenerator{Fill: 0.45, Steps: 4},
+	&MazeGenerator{},
+	&DungeonGenerator{Rooms: 12, MinRoom: 3, MaxRoom: 8},
+	&PerlinGenerator{Scale: 8},
+}
+
+// returns the generator in Generators with the given name, or nil
+func GeneratorByName(name string) TerrainGenerator {
+	for _, gen := range Generators {
+		if gen.Name() == name {
+			return gen
+		}
+	}
+	return nil
+}
+
+// generates terrain with the given seed
+func GenerateTerrain(gen TerrainGenerator, w int, h int, seed int64) [][]int {
+	return gen.Generate(w, h, rand.New(rand.NewSource(seed)))
+}
+
+// returns w x h cells all of the given kind
+func filledCells(w int, h int, kind int) [][]int {
+	t := make([][]int, w)
+	for x := 0; x < w; x++ {
+		t[x] = make([]int, h)
+		for y := 0; y < h; y++ {
+			t[x][y] = kind
+		}
+	}
+	return t
+}
+
+// scatters 3x3 obstacle blobs over empty ground, one centered on each cell
+// with probability Density
+type BlobGenerator struct {
+	Density float64
+}
+
+func (b *BlobGenerator) Name() string { return "blobs" }
+
+func (b *BlobGenerator) Generate(w int, h int, rng *rand.Rand) [][]int {
+	t := filledCells(w, h, EMPTY)
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			if rng.Float64() < b.Density {
+				t[x][y] = OBSTACLE
+				for _, m := range EightConnected.Moves() {
+					xx := x + m.DX
+					yy := y + m.DY
+					if xx < 0 || xx > w-1 ||
+						yy < 0 || yy > h-1 {
+						continue
+					}
+					t[xx][yy] = OBSTACLE
+				}
+			}
+		}
+	}
+	return t
+}
+
+// makes each cell an obstacle with probability Density
+type NoiseGenerator struct {
+	Density float64
+}
+
+func (n *NoiseGenerator) Name() string { return "noise" }
+
+func (n *NoiseGenerator) Generate(w int, h int, rng *rand.Rand) [][]int {
+	t := filledCells(w, h, EMPTY)
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			if rng.Float64() < n.Density {
+				t[x][y] = OBSTACLE
+			}
+		}
+	}
+	return t
+}
+
+// grows caves by cellular automaton: cells start as obstacles with
+// probability Fill, then for each of Steps generations a cell becomes an
+// obstacle if at least 5 of the 9 cells around and including it are (the
+// edge of the grid counting as obstacle), and empty otherwise
+type CaveGenerator struct {
+	Fill  float64
+	Steps int
+}
+
+func (c *CaveGenerator) Name() string { return "caves" }
+
+func (c *CaveGenerator) Generate(w int, h int, rng *rand.Rand) [][]int {
+	t := filledCells(w, h, EMPTY)
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			if rng.Float64() < c.Fill {
+				t[x][y] = OBSTACLE
+			}
+		}
+	}
+	next := filledCells(w, h, EMPTY)
+	for i := 0; i < c.Steps; i++ {
+		for x := 0; x < w; x++ {
+			for y := 0; y < h; y++ {
+				walls := 0
+				for dx := -1; dx <= 1; dx++ {
+					for dy := -1; dy <= 1; dy++ {
+						xx := x + dx
+						yy := y + dy
+						if xx < 0 || xx > w-1 || yy < 0 || yy > h-1 ||
+							t[xx][yy] == OBSTACLE {
+							walls++
+						}
+					}
+				}
+				if walls >= 5 {
+					next[x][y] = OBSTACLE
+				} else {
+					next[x][y] = EMPTY
+				}
+			}
+		}
+		t, next = next, t
+	}
+	return t
+}
+
+// builds a maze of one-cell corridors by recursive division: each chamber
+// is split by a wall with a single gap, horizontally or vertically, until
+// chambers are a corridor wide. Walls lie on odd coordinates and gaps on
+// even ones, so every gap opens onto a corridor
+type MazeGenerator struct{}
+
+func (m *MazeGenerator) Name() string { return "maze" }
+
+func (m *MazeGenerator) Generate(w int, h int, rng *rand.Rand) [][]int {
+	t := filledCells(w, h, EMPTY)
+	m.divide(t, Rect{0, 0, w, h}, rng)
+	return t
+}
+
+// divides the chamber r of t
+func (m *MazeGenerator) divide(t [][]int, r Rect, rng *rand.Rand) {
+	// the odd coordinates a wall could be built on across each axis
+	wallsX := (r.W - 1) / 2
+	wallsY := (r.H - 1) / 2
+	if wallsX < 1 && wallsY < 1 {
+		return
+	}
+	// split across the longer side, so chambers don't grow long and thin
+	vertical := r.W > r.H || (r.W == r.H && rng.Intn(2) == 0)
+	if wallsY < 1 {
+		vertical = true
+	} else if wallsX < 1 {
+		vertical = false
+	}
+	if vertical {
+		wx := r.X + 1 + 2*rng.Intn(wallsX)
+		gap := r.Y + 2*rng.Intn((r.H+1)/2)
+		for y := r.Y; y < r.Y+r.H; y++ {
+			if y != gap {
+				t[wx][y] = OBSTACLE
+			}
+		}
+		m.divide(t, Rect{r.X, r.Y, wx - r.X, r.H}, rng)
+		m.divide(t, Rect{wx + 1, r.Y, r.X + r.W - wx - 1, r.H}, rng)
+	} else {
+		wy := r.Y + 1 + 2*rng.Intn(wallsY)
+		gap := r.X + 2*rng.Intn((r.W+1)/2)
+		for x := r.X; x < r.X+r.W; x++ {
+			if x != gap {
+				t[x][wy] = OBSTACLE
+			}
+		}
+		m.divide(t, Rect{r.X, r.Y, r.W, wy - r.Y}, rng)
+		m.divide(t, Rect{r.X, wy + 1, r.W, r.Y + r.H - wy - 1}, rng)
+	}
+}
+
+// carves up to Rooms rectangular rooms, MinRoom to MaxRoom cells on a side,
+// out of solid rock, joining each to the one before by an L-shaped
+// corridor. Rooms are kept a cell apart, and placements that would overlap
+// are skipped
+type DungeonGenerator struct {
+	Rooms   int
+	MinRoom int
+	MaxRoom int
+}
+
+func (d *DungeonGenerator) Name() string { return "dungeon" }
+
+func (d *DungeonGenerator) Generate(w int, h int, rng *rand.Rand) [][]int {
+	t := filledCells(w, h, OBSTACLE)
+	var rooms []Rect
+	for i := 0; i < d.Rooms; i++ {
+		rw := d.MinRoom + rng.Intn(d.MaxRoom-d.MinRoom+1)
+		rh := d.MinRoom + rng.Intn(d.MaxRoom-d.MinRoom+1)
+		if rw > w-2 || rh > h-2 {
+			continue
+		}
+		room := Rect{1 + rng.Intn(w-rw-1), 1 + rng.Intn(h-rh-1), rw, rh}
+		overlaps := false
+		for _, o := range rooms {
+			if room.X <= o.X+o.W && o.X <= room.X+room.W &&
+				room.Y <= o.Y+o.H && o.Y <= room.Y+room.H {
+				overlaps = true
+				break
+			}
+		}
+		if overlaps {
+			continue
+		}
+		for x := room.X; x < room.X+room.W; x++ {
+			for y := room.Y; y < room.Y+room.H; y++ {
+				t[x][y] = EMPTY
+			}
+		}
+		if len(rooms) > 0 {
+			d.corridor(t, rooms[len(rooms)-1], room, rng)
+		}
+		rooms = append(rooms, room)
+	}
+	return t
+}
+
+// carves a corridor between the centers of rooms a and b, going
+// horizontally then vertically or the other way around
+func (d *DungeonGenerator) corridor(t [][]int, a Rect, b Rect, rng *rand.Rand) {
+	ax, ay := a.X+a.W/2, a.Y+a.H/2
+	bx, by := b.X+b.W/2, b.Y+b.H/2
+	// the corner the corridor turns at
+	cx, cy := bx, ay
+	if rng.Intn(2) == 0 {
+		cx, cy = ax, by
+	}
+	for x := ax; x != cx; x += sign(cx - ax) {
+		t[x][ay] = EMPTY
+	}
+	for y := ay; y != cy; y += sign(cy - ay) {
+		t[ax][y] = EMPTY
+	}
+	for x := cx; x != bx; x += sign(bx - cx) {
+		t[x][cy] = EMPTY
+	}
+	for y := cy; y != by; y += sign(by - cy) {
+		t[cx][y] = EMPTY
+	}
+	t[cx][cy] = EMPTY
+}
+
+// lays weighted terrain by Perlin noise, with features about Scale cells
+// across: water in the lowest ground, then mud, open ground, forest, and
+// obstacles on the highest peaks
+type PerlinGenerator struct {
+	Scale float64
+}
+
+func (p *PerlinGenerator) Name() string { return "perlin" }
+
+func (p *PerlinGenerator) Generate(w int, h int, rng *rand.Rand) [][]int {
+	noise := newPerlin(rng)
+	t := filledCells(w, h, EMPTY)
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			n := noise.at(float64(x)/p.Scale, float64(y)/p.Scale)
+			switch {
+			case n < -0.3:
+				t[x][y] = WATER
+			case n < -0.15:
+				t[x][y] = MUD
+			case n < 0.15:
+				t[x][y] = EMPTY
+			case n < 0.35:
+				t[x][y] = FOREST
+			default:
+				t[x][y] = OBSTACLE
+			}
+		}
+	}
+	return t
+}
+
+// 2D gradient noise in about [-1, 1], with the permutation of the lattice
+// gradients shuffled by the random source
+type perlin struct {
+	perm [512]int
+}
+
+func newPerlin(rng *rand.Rand) *perlin {
+	p := &perlin{}
+	for i, v := range rng.Perm(256) {
+		p.perm[i] = v
+		p.perm[i+256] = v
+	}
+	return p
+}
+
+func (p *perlin) at(x float64, y float64) float64 {
+	x0 := math.Floor(x)
+	y0 := math.Floor(y)
+	xi := int(x0) & 255
+	yi := int(y0) & 255
+	fx := x - x0
+	fy := y - y0
+	u := fade(fx)
+	v := fade(fy)
+	aa := p.perm[p.perm[xi]+yi]
+	ab := p.perm[p.perm[xi]+yi+1]
+	ba := p.perm[p.perm[xi+1]+yi]
+	bb := p.perm[p.perm[xi+1]+yi+1]
+	return lerp(v,
+		lerp(u, grad(aa, fx, fy), grad(ba, fx-1, fy)),
+		lerp(u, grad(ab, fx, fy-1), grad(bb, fx-1, fy-1)))
+}
+
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(t float64, a float64, b float64) float64 {
+	return a + t*(b-a)
+}
+
+// the dot product of x, y with one of 8 gradients chosen by hash
+func grad(hash int, x float64, y float64) float64 {
+	switch hash & 7 {
+	case 0:
+		return x + y
+	case 1:
+		return x - y
+	case 2:
+		return -x + y
+	case 3:
+		return -x - y
+	case 4:
+		return x
+	case 5:
+		return -x
+	case 6:
+		return y
+	default:
+		return -y
+	}
+}
@@ -0,0 +1,121 @@
+package pathfinding
+
+import (
+	"errors"
+)
+
+// special value used for the "From" of the start node
+var NOWHERE = Position{-1, -1}
+
+// the grid model searched by the path computers. Cells[x][y] holds the
+// terrain kind (EMPTY, OBSTACLE, ...) of the cell at x, y, whose cost is
+// looked up in Terrain. The grid covers a WorldW x WorldH rectangle of world
+// space, which is used to convert between cell and world coordinates
+type Grid struct {
+	W       int
+	H       int
+	WorldW  float64
+	WorldH  float64
+	Cells   [][]int
+	Terrain []TerrainType
+}
+
+// Construct a new grid of w x h EMPTY cells, with a world size of one
+// world unit per cell
+func NewGrid(w int, h int) *Grid {
+	cells := make([][]int, w)
+	for x := 0; x < w; x++ {
+		cells[x] = make([]int, h)
+	}
+	terrain := make([]TerrainType, len(DefaultTerrain))
+	copy(terrain, DefaultTerrain)
+	return &Grid{
+		W:       w,
+		H:       h,
+		WorldW:  float64(w),
+		WorldH:  float64(h),
+		Cells:   cells,
+		Terrain: terrain,
+	}
+}
+
+// tests if a position is in the grid bounds
+func (g *Grid) InGrid(p Position) bool {
+	return p.X >= 0 && p.X < g.W &&
+		p.Y >= 0 && p.Y < g.H
+}
+
+// tests if a position contains impassable terrain
+func (g *Grid) IsObstacle(p Position) bool {
+	return g.Terrain[g.Cells[p.X][p.Y]].Cost == IMPASSABLE
+}
+
+// returns the cost multiplier for stepping into the cell at p
+func (g *Grid) CostOf(p Position) int {
+	return g.Terrain[g.Cells[p.X][p.Y]].Cost
+}
+
+// returns the cheapest cost multiplier of any passable terrain type, which
+// scales heuristics so they never overestimate the cost of a path
+func (g *Grid) MinCost() int {
+	min := -1
+	for _, t := range g.Terrain {
+		if t.Cost != IMPASSABLE && (min == -1 || t.Cost < min) {
+			min = t.Cost
+		}
+	}
+	if min < 1 {
+		min = 1
+	}
+	return min
+}
+
+// returns the neighbor position reached from cur by the move m of the given
+// movement model and the cost of stepping into it, or error if not a valid
+// neighbor (off the grid, impassable, or forbidden by the model's corner
+// rule)
+func (g *Grid) NbrOf(cur Position, m Move, model MovementModel) (
+	pos Position, dist int, err error) {
+	nbr := Position{
+		cur.X + m.DX,
+		cur.Y + m.DY,
+	}
+	if !g.InGrid(nbr) ||
+		g.IsObstacle(nbr) ||
+		!model.CanMove(g, cur, m) {
+		return NOWHERE, -1, errors.New("invalid neighbor")
+	}
+	// weight the step by the terrain being entered
+	return nbr, m.Cost * g.CostOf(nbr), nil
+}
+
+// returns the passable cell nearest to p by straight-line distance, or
+// false if the grid has none. p itself may be off the grid
+func (g *Grid) NearestPassable(p Position) (Position, bool) {
+	best := NOWHERE
+	bestD2 := -1
+	// no ring beyond this one can touch the grid
+	dx, dy := absDeltas(p, Position{0, 0})
+	maxR := g.W + g.H + dx + dy
+	// search the edges of square rings of growing radius r around p. No
+	// cell on a ring is nearer than r, so once a cell within r has been
+	// found, no further ring can beat it
+	for r := 0; r <= maxR; r++ {
+		if bestD2 >= 0 && bestD2 <= r*r {
+			break
+		}
+		for i := -r; i <= r; i++ {
+			for _, d := range [4]Position{{i, -r}, {i, r}, {-r, i}, {r, i}} {
+				c := Position{p.X + d.X, p.Y + d.Y}
+				if !g.InGrid(c) || g.IsObstacle(c) {
+					continue
+				}
+				if d2 := d.X*d.X + d.Y*d.Y; bestD2 < 0 || d2 < bestD2 {
+					best = c
+					bestD2 = d2
+				}
+			}
+		}
+	}
+	return best, bestD2 >= 0
+}
@@ -0,0 +1,97 @@
+package pathfinding
+
+import (
+	"math"
+)
+
+// estimates the cost of a path between two cells, in the units of the
+// 10 (straight) / 14 (diagonal) step costs, before terrain weighting. A
+// heuristic which never overestimates (is admissible) gives optimal paths
+type Heuristic interface {
+	Estimate(from Position, to Position) int
+}
+
+// octile distance: diagonal steps (14) as far as possible, then straight
+// steps (10). Exact on an open 8-connected grid, so the best admissible
+// choice there
+type OctileHeuristic struct{}
+
+// Chebyshev distance: every step, straight or diagonal, counts 10.
+// Admissible for any movement where no step costs less than 10 per cell of
+// its longest axis
+type ChebyshevHeuristic struct{}
+
+// straight-line distance (times 10). The integer 14 diagonal is slightly
+// less than 10 * sqrt(2), so this can overestimate on long diagonal runs of
+// an 8-connected grid
+type EuclideanHeuristic struct{}
+
+// Manhattan distance (times 10). Admissible only on a 4-connected grid
+type ManhattanHeuristic struct{}
+
+// always estimates 0, which turns A* into Dijkstra's algorithm
+type ZeroHeuristic struct{}
+
+// scales another heuristic by W. W > 1 gives weighted A*, which expands
+// fewer nodes but may return paths up to W times longer than optimal
+type WeightedHeuristic struct {
+	H Heuristic
+	W float64
+}
+
+// convenience constructor for a WeightedHeuristic
+func Weighted(h Heuristic, w float64) WeightedHeuristic {
+	return WeightedHeuristic{H: h, W: w}
+}
+
+// the absolute x, y distances between two positions
+func absDeltas(p1 Position, p2 Position) (dx int, dy int) {
+	dx = p1.X - p2.X
+	if dx < 0 {
+		dx *= -1
+	}
+	dy = p1.Y - p2.Y
+	if dy < 0 {
+		dy *= -1
+	}
+	return dx, dy
+}
+
+func (OctileHeuristic) Estimate(from Position, to Position) int {
+	dx, dy := absDeltas(from, to)
+	if dx < dy {
+		return 14*dx + 10*(dy-dx)
+	}
+	return 14*dy + 10*(dx-dy)
+}
+
+func (ChebyshevHeuristic) Estimate(from Position, to Position) int {
+	dx, dy := absDeltas(from, to)
+	if dx < dy {
+		return 10 * dy
+	}
+	return 10 * dx
+}
+
+func (EuclideanHeuristic) Estimate(from Position, to Position) int {
+	dx, dy := absDeltas(from, to)
+	return int(10 * math.Sqrt(float64(dx*dx+dy*dy)))
+}
+
+func (ManhattanHeuristic) Estimate(from Position, to Position) int {
+	return ManhattanDistance(from, to)
+}
+
+func (ZeroHeuristic) Estimate(from Position, to Position) int {
+	return 0
+}
+
+func (w WeightedHeuristic) Estimate(from Position, to Position) int {
+	return int(w.W * float64(w.H.Estimate(from, to)))
+}
+
+// Manhattan distance (times 10, since 10 = 1 orthogonal, 14 = 1 diagonal)
+func ManhattanDistance(p1 Position, p2 Position) int {
+	dx, dy := absDeltas(p1, p2)
+	return 10 * (dx + dy)
+}
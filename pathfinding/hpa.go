@@ -0,0 +1,463 @@
+package pathfinding
+
+import (
+	"time"
+)
+
+// entrances at least this wide get a transition at each end instead of a
+// single one in the middle
+const HPA_MAX_ENTRANCE_WIDTH = 6
+
+// Hierarchical pathfinding (HPA*). The grid is cut into square clusters;
+// wherever two neighboring clusters share an open stretch of border (an
+// entrance) a transition joins a cell on each side. The transition cells are
+// the nodes of an abstract graph, joined across borders by single steps and
+// within a cluster by the cost of an A* search bounded to it. Queries search
+// the small abstract graph and then refine each abstract edge into cells.
+//
+// Transitions only cross borders with straight steps, so movement models
+// which can cross a border where no straight step can (corner cutting,
+// knight moves) may find their way around less well than plain A*, and HPA*
+// paths are in general near-optimal rather than optimal
+type HPAPathfinder struct {
+	Grid        *Grid
+	ClusterSize int
+	// the search used to build intra-cluster edges and refine paths. Its
+	// Movement and Heuristic are the ones HPA* uses
+	Computer *AStarPathComputer
+	// number of clusters across and up the grid
+	cw       int
+	ch       int
+	clusters [][]*hpaCluster
+	// abstract graph nodes by cell
+	nodes map[Position]*hpaNode
+	// the transitions across each border
+	borders map[hpaBorder][]hpaTransition
+	// generation counter for the abstract search, as AStarPathComputer.N
+	n  int
+	oh *NodeHeap
+}
+
+type hpaCluster struct {
+	Bounds Rect
+	nodes  []*hpaNode
+}
+
+// a node of the abstract graph. Node is the search scratch, and Node.Pos is
+// the node's cell
+type hpaNode struct {
+	Node
+	// number of transitions using this cell
+	refs  int
+	edges []hpaEdge
+}
+
+type hpaEdge struct {
+	to   *hpaNode
+	cost int
+}
+
+// identifies the border between cluster cx, cy and the cluster east of it
+// (or north of it, if !east)
+type hpaBorder struct {
+	cx   int
+	cy   int
+	east bool
+}
+
+// a pair of facing cells on either side of a border
+type hpaTransition struct {
+	a Position
+	b Position
+}
+
+// Build the abstract graph of a grid cut into clusterSize x clusterSize
+// clusters (those on the far edges may be smaller). clusterSize must be
+// positive
+func NewHPAPathfinder(grid *Grid, clusterSize int) *HPAPathfinder {
+	h := &HPAPathfinder{
+		Grid:        grid,
+		ClusterSize: clusterSize,
+		Computer:    NewAStarPathComputer(grid),
+		cw:          (grid.W + clusterSize - 1) / clusterSize,
+		ch:          (grid.H + clusterSize - 1) / clusterSize,
+		nodes:       make(map[Position]*hpaNode),
+		borders:     make(map[hpaBorder][]hpaTransition),
+		oh:          NewNodeHeap(),
+	}
+	h.clusters = make([][]*hpaCluster, h.cw)
+	for cx := 0; cx < h.cw; cx++ {
+		h.clusters[cx] = make([]*hpaCluster, h.ch)
+		for cy := 0; cy < h.ch; cy++ {
+			r := Rect{
+				X: cx * clusterSize,
+				Y: cy * clusterSize,
+				W: clusterSize,
+				H: clusterSize,
+			}
+			if r.X+r.W > grid.W {
+				r.W = grid.W - r.X
+			}
+			if r.Y+r.H > grid.H {
+				r.H = grid.H - r.Y
+			}
+			h.clusters[cx][cy] = &hpaCluster{Bounds: r}
+		}
+	}
+	// build every border, then every cluster's edges
+	for cx := 0; cx < h.cw; cx++ {
+		for cy := 0; cy < h.ch; cy++ {
+			if cx+1 < h.cw {
+				h.rebuildBorder(hpaBorder{cx, cy, true})
+			}
+			if cy+1 < h.ch {
+				h.rebuildBorder(hpaBorder{cx, cy, false})
+			}
+		}
+	}
+	for cx := 0; cx < h.cw; cx++ {
+		for cy := 0; cy < h.ch; cy++ {
+			h.rebuildCluster(cx, cy)
+		}
+	}
+	return h
+}
+
+// the cluster coordinates of the cluster holding p
+func (h *HPAPathfinder) clusterOf(p Position) (cx int, cy int) {
+	return p.X / h.ClusterSize, p.Y / h.ClusterSize
+}
+
+// Bring the abstract graph up to date after the cells at the given positions
+// have changed in Grid.Cells. Only the borders the cells lie on and the
+// clusters touching them are rebuilt
+func (h *HPAPathfinder) Update(changed []Position) {
+	borders := make(map[hpaBorder]bool)
+	clusters := make(map[[2]int]bool)
+	for _, p := range changed {
+		if !h.Grid.InGrid(p) {
+			continue
+		}
+		cx, cy := h.clusterOf(p)
+		clusters[[2]int{cx, cy}] = true
+		r := h.clusters[cx][cy].Bounds
+		// a cell on the edge of its cluster is part of the border with
+		// the cluster on the other side, if there is one
+		if p.X == r.X+r.W-1 && cx+1 < h.cw {
+			borders[hpaBorder{cx, cy, true}] = true
+			clusters[[2]int{cx + 1, cy}] = true
+		}
+		if p.X == r.X && cx > 0 {
+			borders[hpaBorder{cx - 1, cy, true}] = true
+			clusters[[2]int{cx - 1, cy}] = true
+		}
+		if p.Y == r.Y+r.H-1 && cy+1 < h.ch {
+			borders[hpaBorder{cx, cy, false}] = true
+			clusters[[2]int{cx, cy + 1}] = true
+		}
+		if p.Y == r.Y && cy > 0 {
+			borders[hpaBorder{cx, cy - 1, false}] = true
+			clusters[[2]int{cx, cy - 1}] = true
+		}
+	}
+	for b := range borders {
+		h.rebuildBorder(b)
+	}
+	for c := range clusters {
+		h.rebuildCluster(c[0], c[1])
+	}
+}
+
+// recompute the transitions across a border, adding and removing abstract
+// nodes and the edges across the border as needed. The intra-cluster edges
+// of the clusters on both sides must be rebuilt afterward
+func (h *HPAPathfinder) rebuildBorder(b hpaBorder) {
+	for _, t := range h.borders[b] {
+		a := h.nodes[t.a]
+		bn := h.nodes[t.b]
+		a.removeEdgesTo(bn)
+		bn.removeEdgesTo(a)
+		h.release(a)
+		h.release(bn)
+	}
+	ts := h.findTransitions(b)
+	h.borders[b] = ts
+	for _, t := range ts {
+		a := h.acquire(t.a)
+		bn := h.acquire(t.b)
+		// crossing is a single straight step into the other cell
+		a.edges = append(a.edges, hpaEdge{bn, 10 * h.Grid.CostOf(t.b)})
+		bn.edges = append(bn.edges, hpaEdge{a, 10 * h.Grid.CostOf(t.a)})
+	}
+}
+
+// scans a border for entrances (maximal runs of facing passable cells) and
+// places transitions on them
+func (h *HPAPathfinder) findTransitions(b hpaBorder) []hpaTransition {
+	r := h.clusters[b.cx][b.cy].Bounds
+	// along = the cells of the border on cluster cx, cy's side, and step =
+	// the offset across the border
+	var along []Position
+	var step Position
+	if b.east {
+		for y := r.Y; y < r.Y+r.H; y++ {
+			along = append(along, Position{r.X + r.W - 1, y})
+		}
+		step = Position{1, 0}
+	} else {
+		for x := r.X; x < r.X+r.W; x++ {
+			along = append(along, Position{x, r.Y + r.H - 1})
+		}
+		step = Position{0, 1}
+	}
+	open := func(i int) bool {
+		p := along[i]
+		return !h.Grid.IsObstacle(p) &&
+			!h.Grid.IsObstacle(Position{p.X + step.X, p.Y + step.Y})
+	}
+	transition := func(i int) hpaTransition {
+		p := along[i]
+		return hpaTransition{p, Position{p.X + step.X, p.Y + step.Y}}
+	}
+	var ts []hpaTransition
+	for i := 0; i < len(along); {
+		if !open(i) {
+			i++
+			continue
+		}
+		j := i
+		for j+1 < len(along) && open(j+1) {
+			j++
+		}
+		if j-i+1 < HPA_MAX_ENTRANCE_WIDTH {
+			ts = append(ts, transition((i+j)/2))
+		} else {
+			ts = append(ts, transition(i), transition(j))
+		}
+		i = j + 1
+	}
+	return ts
+}
+
+// returns the abstract node at p, creating it if needed, and counts one more
+// transition using it
+func (h *HPAPathfinder) acquire(p Position) *hpaNode {
+	n, ok := h.nodes[p]
+	if !ok {
+		n = &hpaNode{Node: Node{Pos: p}}
+		h.nodes[p] = n
+	}
+	n.refs++
+	return n
+}
+
+// counts one less transition using n, deleting it when none are left
+func (h *HPAPathfinder) release(n *hpaNode) {
+	n.refs--
+	if n.refs == 0 {
+		delete(h.nodes, n.Pos)
+	}
+}
+
+func (n *hpaNode) removeEdgesTo(to *hpaNode) {
+	edges := n.edges[:0]
+	for _, e := range n.edges {
+		if e.to != to {
+			edges = append(edges, e)
+		}
+	}
+	n.edges = edges
+}
+
+// collects the abstract nodes of a cluster and recomputes the edges between
+// them with bounded A* searches
+func (h *HPAPathfinder) rebuildCluster(cx int, cy int) {
+	cl := h.clusters[cx][cy]
+	r := cl.Bounds
+	// abstract nodes only ever sit on a cluster's outer ring of cells
+	cl.nodes = cl.nodes[:0]
+	for x := r.X; x < r.X+r.W; x++ {
+		for y := r.Y; y < r.Y+r.H; y++ {
+			if x != r.X && x != r.X+r.W-1 && y != r.Y && y != r.Y+r.H-1 {
+				continue
+			}
+			if n, ok := h.nodes[Position{x, y}]; ok {
+				cl.nodes = append(cl.nodes, n)
+			}
+		}
+	}
+	// drop the old intra-cluster edges, keeping those across borders
+	for _, n := range cl.nodes {
+		edges := n.edges[:0]
+		for _, e := range n.edges {
+			if !r.Contains(e.to.Pos) {
+				edges = append(edges, e)
+			}
+		}
+		n.edges = edges
+	}
+	opts := SearchOptions{Bounds: &cl.Bounds}
+	for _, from := range cl.nodes {
+		for _, to := range cl.nodes {
+			if from == to {
+				continue
+			}
+			res := h.Computer.AStarPathWithOptions(from.Pos, to.Pos, opts)
+			if res.Err == nil {
+				from.edges = append(from.edges, hpaEdge{to, res.Cost})
+			}
+		}
+	}
+}
+
+// searches for a path from start to end, first over the abstract graph and
+// then refining it into cells
+func (h *HPAPathfinder) HPAPath(start Position, end Position) (res PathResult) {
+	t0 := time.Now()
+	defer func() { res.Elapsed = time.Since(t0) }()
+	if res.Err = checkEndpoints(h.Grid, start, end); res.Err != nil {
+		return res
+	}
+	if start == end {
+		res.Path = []Position{start}
+		return res
+	}
+	scx, scy := h.clusterOf(start)
+	ecx, ecy := h.clusterOf(end)
+	startCl := h.clusters[scx][scy]
+	endCl := h.clusters[ecx][ecy]
+
+	// join start and end to the abstract graph, with temporary nodes if they
+	// aren't transition cells, and temporary edges within their clusters
+	var temps []*hpaNode
+	edgeCounts := make(map[*hpaNode]int)
+	node := func(p Position) *hpaNode {
+		n, ok := h.nodes[p]
+		if !ok {
+			n = &hpaNode{Node: Node{Pos: p}}
+			h.nodes[p] = n
+			temps = append(temps, n)
+		}
+		return n
+	}
+	addEdge := func(from *hpaNode, to *hpaNode, bounds *Rect) {
+		if _, ok := edgeCounts[from]; !ok {
+			edgeCounts[from] = len(from.edges)
+		}
+		r := h.Computer.AStarPathWithOptions(
+			from.Pos, to.Pos, SearchOptions{Bounds: bounds})
+		res.Expanded += r.Expanded
+		res.Generated += r.Generated
+		if r.Err == nil {
+			from.edges = append(from.edges, hpaEdge{to, r.Cost})
+		}
+	}
+	sn := node(start)
+	en := node(end)
+	for _, n := range startCl.nodes {
+		if n != sn {
+			addEdge(sn, n, &startCl.Bounds)
+		}
+	}
+	for _, n := range endCl.nodes {
+		if n != en {
+			addEdge(n, en, &endCl.Bounds)
+		}
+	}
+	if startCl == endCl {
+		addEdge(sn, en, &startCl.Bounds)
+	}
+	defer func() {
+		for n, count := range edgeCounts {
+			n.edges = n.edges[:count]
+		}
+		for _, n := range temps {
+			delete(h.nodes, n.Pos)
+		}
+	}()
+
+	abstract := h.abstractSearch(sn, en, &res)
+	if abstract == nil {
+		res.Err = ErrNoPath
+		return res
+	}
+
+	// refine: steps across borders are already cells; everything else is a
+	// search within one cluster
+	res.Path = []Position{start}
+	for i := 1; i < len(abstract); i++ {
+		from, to := abstract[i-1], abstract[i]
+		fcx, fcy := h.clusterOf(from)
+		tcx, tcy := h.clusterOf(to)
+		if fcx != tcx || fcy != tcy {
+			res.Path = append(res.Path, to)
+			res.Cost += 10 * h.Grid.CostOf(to)
+			continue
+		}
+		r := h.Computer.AStarPathWithOptions(from, to,
+			SearchOptions{Bounds: &h.clusters[fcx][fcy].Bounds})
+		res.Expanded += r.Expanded
+		res.Generated += r.Generated
+		if r.Err != nil {
+			// the grid has changed since the graph was last updated
+			res.Path = nil
+			res.Cost = 0
+			res.Err = ErrNoPath
+			return res
+		}
+		res.Path = append(res.Path, r.Path[1:]...)
+		res.Cost += r.Cost
+	}
+	return res
+}
+
+// A* over the abstract graph, returning the cells of the abstract nodes on
+// the cheapest path from sn to en, or nil if there is none
+func (h *HPAPathfinder) abstractSearch(
+	sn *hpaNode, en *hpaNode, res *PathResult) []Position {
+	heuristic := h.Computer.heuristicFor(SearchOptions{})
+	minCost := h.Grid.MinCost()
+	h.oh.Clear()
+	h.n += 2
+	sn.Node = Node{
+		Pos:       sn.Pos,
+		WhichList: h.n,
+		H:         minCost * heuristic.Estimate(sn.Pos, en.Pos),
+	}
+	h.oh.Add(&sn.Node)
+	res.Generated++
+	for h.oh.Len() > 0 {
+		curNode, err := h.oh.Pop()
+		if err != nil {
+			break
+		}
+		res.Expanded++
+		curNode.WhichList = h.n + 1
+		cur := h.nodes[curNode.Pos]
+		if cur == en {
+			return tracePath(curNode)
+		}
+		for _, e := range cur.edges {
+			nbr := e.to
+			g := cur.G + e.cost
+			isClosed := nbr.WhichList == h.n+1
+			if isClosed && g >= nbr.G {
+				continue
+			}
+			if nbr.WhichList != h.n {
+				nbr.From = curNode
+				nbr.G = g
+				nbr.H = minCost * heuristic.Estimate(nbr.Pos, en.Pos)
+				nbr.WhichList = h.n
+				h.oh.Add(&nbr.Node)
+				res.Generated++
+			} else if g < nbr.G {
+				nbr.From = curNode
+				nbr.G = g
+				nbr.F = nbr.G + nbr.H
+				h.oh.Modified(&nbr.Node)
+			}
+		}
+	}
+	return nil
+}
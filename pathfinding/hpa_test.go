@@ -0,0 +1,77 @@
+package pathfinding
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// an edge of the abstract graph, by the cells it joins
+type hpaEdgeKey struct {
+	from Position
+	to   Position
+	cost int
+}
+
+// the abstract graph of h, as a count of each edge, with each node counted
+// as an edge to itself costing the transitions using it
+func hpaGraph(h *HPAPathfinder) map[hpaEdgeKey]int {
+	graph := make(map[hpaEdgeKey]int)
+	for p, n := range h.nodes {
+		graph[hpaEdgeKey{p, p, n.refs}]++
+		for _, e := range n.edges {
+			graph[hpaEdgeKey{p, e.to.Pos, e.cost}]++
+		}
+	}
+	return graph
+}
+
+// rebuilding only what changed cells touch must leave the same abstract
+// graph as building it afresh, and refined paths must be made of moves
+func TestHPAUpdateMatchesFreshBuild(t *testing.T) {
+	const w, h = 40, 30
+	rng := rand.New(rand.NewSource(8))
+	kinds := []int{EMPTY, OBSTACLE, OBSTACLE, ROAD, MUD}
+	for run := 0; run < 10; run++ {
+		g := mixedGrid(rng, w, h)
+		size := 4 + rng.Intn(8)
+		hpa := NewHPAPathfinder(g, size)
+		for edit := 0; edit < 10; edit++ {
+			var changed []Position
+			for i := 0; i < 1+rng.Intn(8); i++ {
+				p := Position{rng.Intn(w), rng.Intn(h)}
+				g.Cells[p.X][p.Y] = kinds[rng.Intn(len(kinds))]
+				changed = append(changed, p)
+			}
+			hpa.Update(changed)
+			fresh := NewHPAPathfinder(g, size)
+			got := hpaGraph(hpa)
+			want := hpaGraph(fresh)
+			for e := range want {
+				got[e] -= want[e]
+			}
+			for e, n := range got {
+				if n != 0 {
+					t.Fatalf("run %d, edit %d (cluster size %d): updated "+
+						"graph has %+d of %+v", run, edit, size, n, e)
+				}
+			}
+			for i := 0; i < 10; i++ {
+				start := Position{rng.Intn(w), rng.Intn(h)}
+				end := Position{rng.Intn(w), rng.Intn(h)}
+				a := hpa.HPAPath(start, end)
+				b := fresh.HPAPath(start, end)
+				if a.Err != b.Err || a.Cost != b.Cost {
+					t.Errorf("%v to %v: updated cost %d (%v), fresh cost "+
+						"%d (%v)", start, end, a.Cost, a.Err, b.Cost, b.Err)
+					continue
+				}
+				if a.Err == nil && (a.Path[0] != start ||
+					a.Path[len(a.Path)-1] != end ||
+					pathCostUnder(t, g, EightConnected, a.Path) != a.Cost) {
+					t.Errorf("%v to %v: path %v isn't %d worth of moves",
+						start, end, a.Path, a.Cost)
+				}
+			}
+		}
+	}
+}
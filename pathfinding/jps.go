@@ -0,0 +1,238 @@
+package pathfinding
+
+import (
+	"time"
+)
+
+// Jump Point Search: an A* over "jump points" only, skipping the long runs of
+// symmetric paths an open grid has between them. It uses the same Nodes,
+// heap and generation counter as AStarPath, and EightConnected movement
+// (no corner cutting) whatever the computer's or the options' Movement is.
+// Paths cost the same as AStarPath's, but only if every passable cell
+// costs the same to enter: JPS assumes uniform terrain
+func (c *AStarPathComputer) JumpPointPath(
+	start Position, end Position) PathResult {
+	return c.JumpPointPathWithOptions(start, end, SearchOptions{})
+}
+
+// like JumpPointPath, but with per-query options. Movement is ignored, and
+// Bounds aren't supported: a query setting them fails with ErrNoBounds. The
+// computer's Components are used as by AStarPath, if labelled for
+// EightConnected movement
+func (c *AStarPathComputer) JumpPointPathWithOptions(
+	start Position, end Position, opts SearchOptions) (res PathResult) {
+	t0 := time.Now()
+	defer func() { res.Elapsed = time.Since(t0) }()
+	if opts.Bounds != nil {
+		res.Err = ErrNoBounds
+		return res
+	}
+	if res.Err = checkEndpoints(c.Grid, start, end); res.Err != nil {
+		return res
+	}
+	opts.Movement = EightConnected
+	if c.Components != nil && c.Components.Movement == opts.Movement &&
+		!c.Components.Reachable(start, end) {
+		// leave no cell open or closed from the last search
+		c.N += 2
+		res.Err = ErrNoPath
+		return res
+	}
+	minCost := c.Grid.MinCost()
+	heuristic := c.heuristicFor(opts)
+
+	c.begin(start, end, minCost*heuristic.Estimate(start, end))
+	res.Generated++
+	// scratch for the pruned directions of each expanded node
+	dirs := make([][2]int, 0, 8)
+
+	for c.OH.Len() > 0 {
+		cur, err := c.OH.Pop()
+		if err != nil {
+			break
+		}
+		res.Expanded++
+		cur.WhichList = c.N + 1
+		cur.Order = res.Expanded
+		if cur == c.endNode {
+			res.Path = c.expandJumps(cur)
+			res.Cost = c.pathCost(res.Path)
+			return res
+		}
+		// jump from cur in each direction its parent leaves worth exploring,
+		// and consider each jump point found as a successor
+		dirs = c.prunedDirections(cur, dirs[:0])
+		for _, d := range dirs {
+			jp, ok := c.jump(cur.Pos, d[0], d[1], end)
+			if !ok {
+				continue
+			}
+			nbr := &c.Nodes[jp.X][jp.Y]
+			g := cur.G + OctileHeuristic{}.Estimate(cur.Pos, jp)*c.Grid.CostOf(jp)
+			h := minCost * heuristic.Estimate(jp, end)
+			if c.consider(c.OH, cur, nbr, g, h) {
+				res.Generated++
+			}
+		}
+	}
+	res.Err = ErrNoPath
+	return res
+}
+
+// whether the cell x, y is in the grid and passable
+func (c *AStarPathComputer) walkable(x int, y int) bool {
+	p := Position{x, y}
+	return c.Grid.InGrid(p) && !c.Grid.IsObstacle(p)
+}
+
+// appends to dirs the directions worth searching from n, given the direction
+// it was reached in: the natural continuations of that direction plus any
+// neighbors forced by adjacent obstacles. The start node searches everywhere
+func (c *AStarPathComputer) prunedDirections(
+	n *Node, dirs [][2]int) [][2]int {
+	x, y := n.Pos.X, n.Pos.Y
+	if n.From == nil {
+		for _, m := range EightConnected.Moves() {
+			if _, _, err := c.Grid.NbrOf(n.Pos, m, EightConnected); err == nil {
+				dirs = append(dirs, [2]int{m.DX, m.DY})
+			}
+		}
+		return dirs
+	}
+	dx := sign(x - n.From.Pos.X)
+	dy := sign(y - n.From.Pos.Y)
+	switch {
+	// diagonal: both straight components, and the diagonal itself if
+	// neither corner is blocked
+	case dx != 0 && dy != 0:
+		nextY := c.walkable(x, y+dy)
+		nextX := c.walkable(x+dx, y)
+		if nextY {
+			dirs = append(dirs, [2]int{0, dy})
+		}
+		if nextX {
+			dirs = append(dirs, [2]int{dx, 0})
+		}
+		if nextY && nextX {
+			dirs = append(dirs, [2]int{dx, dy})
+		}
+	// horizontal: straight on, and the sides with the diagonals toward them
+	case dx != 0:
+		next := c.walkable(x+dx, y)
+		up := c.walkable(x, y+1)
+		down := c.walkable(x, y-1)
+		if next {
+			dirs = append(dirs, [2]int{dx, 0})
+			if up {
+				dirs = append(dirs, [2]int{dx, 1})
+			}
+			if down {
+				dirs = append(dirs, [2]int{dx, -1})
+			}
+		}
+		if up {
+			dirs = append(dirs, [2]int{0, 1})
+		}
+		if down {
+			dirs = append(dirs, [2]int{0, -1})
+		}
+	// vertical: as horizontal, with x and y swapped
+	default:
+		next := c.walkable(x, y+dy)
+		right := c.walkable(x+1, y)
+		left := c.walkable(x-1, y)
+		if next {
+			dirs = append(dirs, [2]int{0, dy})
+			if right {
+				dirs = append(dirs, [2]int{1, dy})
+			}
+			if left {
+				dirs = append(dirs, [2]int{-1, dy})
+			}
+		}
+		if right {
+			dirs = append(dirs, [2]int{1, 0})
+		}
+		if left {
+			dirs = append(dirs, [2]int{-1, 0})
+		}
+	}
+	return dirs
+}
+
+// steps from p in direction dx, dy until reaching a jump point (the end, a
+// cell with a forced neighbor, or for diagonals a cell from which a
+// straight jump finds one), returning false if an obstacle or the grid edge
+// is hit first. The step into the first cell must already be known legal
+func (c *AStarPathComputer) jump(
+	p Position, dx int, dy int, end Position) (Position, bool) {
+	x, y := p.X, p.Y
+	for {
+		x += dx
+		y += dy
+		if !c.walkable(x, y) {
+			return NOWHERE, false
+		}
+		if x == end.X && y == end.Y {
+			return Position{x, y}, true
+		}
+		switch {
+		case dx != 0 && dy != 0:
+			// a diagonal cell is a jump point if a straight jump out of it
+			// finds one
+			here := Position{x, y}
+			if _, ok := c.jump(here, dx, 0, end); ok {
+				return here, true
+			}
+			if _, ok := c.jump(here, 0, dy, end); ok {
+				return here, true
+			}
+			// the next diagonal step may not cut a corner
+			if !c.walkable(x+dx, y) || !c.walkable(x, y+dy) {
+				return NOWHERE, false
+			}
+		case dx != 0:
+			// a side cell whose diagonal approach from behind is blocked can
+			// only be reached optimally through here
+			if (c.walkable(x, y+1) && !c.walkable(x-dx, y+1)) ||
+				(c.walkable(x, y-1) && !c.walkable(x-dx, y-1)) {
+				return Position{x, y}, true
+			}
+		default:
+			if (c.walkable(x+1, y) && !c.walkable(x+1, y-dy)) ||
+				(c.walkable(x-1, y) && !c.walkable(x-1, y-dy)) {
+				return Position{x, y}, true
+			}
+		}
+	}
+}
+
+// builds the full cell-by-cell path from start to the end node, filling in
+// the straight or diagonal runs between consecutive jump points
+func (c *AStarPathComputer) expandJumps(end *Node) []Position {
+	jumps := tracePath(end)
+	path := []Position{jumps[0]}
+	for i := 1; i < len(jumps); i++ {
+		cur := jumps[i-1]
+		dx := sign(jumps[i].X - cur.X)
+		dy := sign(jumps[i].Y - cur.Y)
+		for cur != jumps[i] {
+			cur = Position{cur.X + dx, cur.Y + dy}
+			path = append(path, cur)
+		}
+	}
+	return path
+}
+
+// the terrain-weighted cost of walking a cell-by-cell path
+func (c *AStarPathComputer) pathCost(path []Position) int {
+	cost := 0
+	for i := 1; i < len(path); i++ {
+		step := 10
+		if path[i].X != path[i-1].X && path[i].Y != path[i-1].Y {
+			step = 14
+		}
+		cost += step * c.Grid.CostOf(path[i])
+	}
+	return cost
+}
@@ -0,0 +1,55 @@
+package pathfinding
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// JPS must find paths costing the same as A*'s on every generator's
+// terrain, as long as the terrain is uniform
+func TestJumpPointPathMatchesAStar(t *testing.T) {
+	const w, h = 64, 48
+	for _, gen := range Generators {
+		t.Run(gen.Name(), func(t *testing.T) {
+			g := NewGrid(w, h)
+			g.Terrain = append([]TerrainType(nil), UniformTerrain...)
+			g.Cells = GenerateTerrain(gen, w, h, 7)
+			c := NewAStarPathComputer(g)
+			rng := rand.New(rand.NewSource(7))
+			for i := 0; i < 100; i++ {
+				start := Position{rng.Intn(w), rng.Intn(h)}
+				end := Position{rng.Intn(w), rng.Intn(h)}
+				a := c.AStarPath(start, end)
+				j := c.JumpPointPath(start, end)
+				if a.Err != j.Err || a.Cost != j.Cost {
+					t.Errorf("%v to %v: A* cost %d (%v), JPS cost %d (%v)",
+						start, end, a.Cost, a.Err, j.Cost, j.Err)
+				}
+				if j.Err == nil &&
+					(j.Path[0] != start || j.Path[len(j.Path)-1] != end) {
+					t.Errorf("%v to %v: JPS path runs %v to %v",
+						start, end, j.Path[0], j.Path[len(j.Path)-1])
+				}
+			}
+		})
+	}
+}
+
+func TestJumpPointPathOptions(t *testing.T) {
+	g := NewGrid(10, 10)
+	for y := 0; y < 10; y++ {
+		g.Cells[5][y] = OBSTACLE
+	}
+	c := NewAStarPathComputer(g)
+	res := c.JumpPointPathWithOptions(Position{1, 1}, Position{3, 3},
+		SearchOptions{Bounds: &Rect{X: 0, Y: 0, W: 5, H: 5}})
+	if res.Err != ErrNoBounds {
+		t.Errorf("with bounds: got %v, want ErrNoBounds", res.Err)
+	}
+	c.Components = NewComponents(g, EightConnected)
+	res = c.JumpPointPath(Position{1, 1}, Position{8, 8})
+	if res.Err != ErrNoPath || res.Expanded != 0 {
+		t.Errorf("across the wall: got %v after %d expansions, "+
+			"want ErrNoPath after none", res.Err, res.Expanded)
+	}
+}
@@ -0,0 +1,229 @@
+package pathfinding
+
+import (
+	"math"
+)
+
+// steps through the cells of the Bresenham line from a to b, both included:
+// one cell for each cell along the longer axis, so that consecutive cells
+// can meet only at a corner, and the line can slip between two obstacles
+// which meet at one. Use it as
+//
+//	for it := NewBresenham(a, b); it.Next(); {
+//		... it.Pos ...
+//	}
+type Bresenham struct {
+	// the current cell, once Next has returned true
+	Pos     Position
+	end     Position
+	dx      int
+	dy      int
+	sx      int
+	sy      int
+	err     int
+	started bool
+}
+
+func NewBresenham(a Position, b Position) *Bresenham {
+	dx, dy := absDeltas(a, b)
+	return &Bresenham{
+		Pos: a,
+		end: b,
+		dx:  dx,
+		dy:  -dy,
+		sx:  sign(b.X - a.X),
+		sy:  sign(b.Y - a.Y),
+		err: dx - dy,
+	}
+}
+
+// moves to the next cell, returning false once past b
+func (it *Bresenham) Next() bool {
+	if !it.started {
+		it.started = true
+		return true
+	}
+	if it.Pos == it.end {
+		return false
+	}
+	e2 := 2 * it.err
+	if e2 >= it.dy {
+		it.err += it.dy
+		it.Pos.X += it.sx
+	}
+	if e2 <= it.dx {
+		it.err += it.dx
+		it.Pos.Y += it.sy
+	}
+	return true
+}
+
+// steps through every cell which the segment from the center of a to the
+// center of b passes through the inside of, in order, a and b included.
+// Consecutive cells share a side, except where the segment passes exactly
+// through a corner, when the step is diagonal; the two cells beside the
+// corner are touched by the segment only at that point and aren't stepped
+// through, so that the caller can decide on them as a corner rule would.
+// Use it as Bresenham
+type Supercover struct {
+	// the current cell, once Next has returned true
+	Pos Position
+	// the step from the previous cell to Pos (no step for a), with no cost
+	Step Move
+	dx   int
+	dy   int
+	sx   int
+	sy   int
+	// the cell boundaries crossed so far in x and y
+	ix      int
+	iy      int
+	started bool
+}
+
+func NewSupercover(a Position, b Position) *Supercover {
+	dx, dy := absDeltas(a, b)
+	return &Supercover{
+		Pos: a,
+		dx:  dx,
+		dy:  dy,
+		sx:  sign(b.X - a.X),
+		sy:  sign(b.Y - a.Y),
+	}
+}
+
+// moves to the next cell, returning false once past b
+func (it *Supercover) Next() bool {
+	if !it.started {
+		it.started = true
+		return true
+	}
+	if it.ix >= it.dx && it.iy >= it.dy {
+		return false
+	}
+	// the segment crosses the next x boundary at t = (2ix+1) / 2dx and the
+	// next y boundary at t = (2iy+1) / 2dy; d compares the two
+	d := (2*it.ix+1)*it.dy - (2*it.iy+1)*it.dx
+	switch {
+	case d == 0:
+		it.Step = Move{DX: it.sx, DY: it.sy}
+		it.ix++
+		it.iy++
+	case d < 0:
+		it.Step = Move{DX: it.sx}
+		it.ix++
+	default:
+		it.Step = Move{DY: it.sy}
+		it.iy++
+	}
+	it.Pos.X += it.Step.DX
+	it.Pos.Y += it.Step.DY
+	return true
+}
+
+// tests if the segment between the centers of cells a and b crosses only
+// passable cells, where it passes exactly through a corner squeezing past
+// the cells beside it only if EightConnected movement could
+func (g *Grid) LineOfSight(a Position, b Position) bool {
+	return g.LineOfSightUnder(a, b, EightConnected)
+}
+
+// LineOfSight, passing through corners as the corner rule of model allows
+// its diagonal moves to, just as NbrOf would
+func (g *Grid) LineOfSightUnder(
+	a Position, b Position, model MovementModel) bool {
+	prev := a
+	for it := NewSupercover(a, b); it.Next(); {
+		if g.blocked(it.Pos) {
+			return false
+		}
+		if it.Step.DX != 0 && it.Step.DY != 0 &&
+			!model.CanMove(g, prev, it.Step) {
+			return false
+		}
+		prev = it.Pos
+	}
+	return true
+}
+
+// casts a ray from the world-space point origin in the direction dir, for
+// up to maxDist world units (which may be infinite), returning the first
+// cell it enters which blocks it, the world-space point where it enters
+// that cell, and true. A cell blocks the ray if it's impassable or outside
+// the grid, so that a ray starting in the grid always stops at its edge;
+// where the ray passes exactly through a corner, either cell beside the
+// corner blocks it. If nothing blocks the ray within maxDist, returns the
+// cell and point it reaches and false
+func (g *Grid) RayCast(origin Vec2D, dir Vec2D, maxDist float64) (
+	cell Position, hit Vec2D, blocked bool) {
+	cw := g.CellWorldW()
+	ch := g.CellWorldH()
+	cell = Position{
+		int(math.Floor(origin.X / cw)),
+		int(math.Floor(origin.Y / ch))}
+	if g.blocked(cell) {
+		return cell, origin, true
+	}
+	m := dir.Magnitude()
+	if m == 0 {
+		return cell, origin, false
+	}
+	u := dir.Scale(1 / m)
+	sx := fsign(u.X)
+	sy := fsign(u.Y)
+	// rounding could put a crossing this close to a corner on either side
+	// of it, so it's taken as passing through the corner
+	eps := 1e-9 * (cw + ch)
+	// the distance along the ray to the cell's next boundary on one axis
+	next := func(c int, s int, o float64, u float64, size float64) float64 {
+		if s == 0 {
+			return math.Inf(1)
+		}
+		bound := float64(c) * size
+		if s > 0 {
+			bound += size
+		}
+		return (bound - o) / u
+	}
+	for {
+		tx := next(cell.X, sx, origin.X, u.X, cw)
+		ty := next(cell.Y, sy, origin.Y, u.Y, ch)
+		t := math.Min(tx, ty)
+		if t > maxDist {
+			return cell, origin.Add(u.Scale(maxDist)), false
+		}
+		hit = origin.Add(u.Scale(t))
+		switch {
+		case math.Abs(tx-ty) < eps:
+			for _, side := range brushedCells(cell, Move{DX: sx, DY: sy}) {
+				if g.blocked(side) {
+					return side, hit, true
+				}
+			}
+			cell.X += sx
+			cell.Y += sy
+		case tx < ty:
+			cell.X += sx
+		default:
+			cell.Y += sy
+		}
+		if g.blocked(cell) {
+			return cell, hit, true
+		}
+	}
+}
+
+// tests if p is impassable or outside the grid
+func (g *Grid) blocked(p Position) bool {
+	return !g.InGrid(p) || g.IsObstacle(p)
+}
+
+func fsign(x float64) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,255 @@
+package pathfinding
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Grids are saved as text in the Moving AI benchmark .map format:
+//
+//	type octile
+//	height 3
+//	width 4
+//	map
+//	..@.
+//	.TT.
+//	....
+//
+// followed by height rows of width characters, one per cell. Row y of the
+// map holds the cells Cells[0][y] .. Cells[width-1][y], so y counts down the
+// file. The characters are
+//
+//	.  G   EMPTY
+//	@  O   OBSTACLE
+//	T      TREES (impassable)
+//	W      WATER
+//	S      MUD (swamp)
+//	R      ROAD (not in the Moving AI format)
+//	F      FOREST (not in the Moving AI format)
+//
+// In the Moving AI benchmarks water can only be entered from water; here it
+// is ordinary terrain with the cost given by the terrain table. START and
+// END cells are saved as EMPTY.
+//
+// The JSON variant (see GridJSON) holds the same rows plus the terrain
+// table and the start and goal, if any
+
+// the character each terrain kind is saved as
+var kindChars = map[int]byte{
+	EMPTY:    '.',
+	OBSTACLE: '@',
+	START:    '.',
+	END:      '.',
+	ROAD:     'R',
+	FOREST:   'F',
+	MUD:      'S',
+	WATER:    'W',
+	TREES:    'T',
+}
+
+// the terrain kind each map character is loaded as
+var charKinds = map[byte]int{
+	'.': EMPTY,
+	'G': EMPTY,
+	'@': OBSTACLE,
+	'O': OBSTACLE,
+	'R': ROAD,
+	'F': FOREST,
+	'S': MUD,
+	'W': WATER,
+	'T': TREES,
+}
+
+// reads a grid in the .map format. Its terrain table is a copy of
+// DefaultTerrain and its world size is one unit per cell
+func LoadGrid(r io.Reader) (*Grid, error) {
+	sc := bufio.NewScanner(r)
+	line := 0
+	w, h := -1, -1
+	// header lines, up to "map"
+	for {
+		if !sc.Scan() {
+			if err := sc.Err(); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("map: no \"map\" line")
+		}
+		line++
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "map" {
+			break
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("map line %d: bad header %q",
+				line, sc.Text())
+		}
+		switch fields[0] {
+		case "type":
+			// every map is searched with whichever movement model the
+			// caller chooses, so the type is only informative
+		case "height", "width":
+			n, err := strconv.Atoi(fields[1])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("map line %d: bad %s %q",
+					line, fields[0], fields[1])
+			}
+			if fields[0] == "height" {
+				h = n
+			} else {
+				w = n
+			}
+		default:
+			return nil, fmt.Errorf("map line %d: unknown header %q",
+				line, fields[0])
+		}
+	}
+	if w < 0 || h < 0 {
+		return nil, fmt.Errorf("map: missing width or height")
+	}
+	rows := make([]string, 0, h)
+	for len(rows) < h && sc.Scan() {
+		line++
+		rows = append(rows, strings.TrimRight(sc.Text(), "\r"))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	g := NewGrid(w, h)
+	if err := g.setRows(rows); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// writes the grid in the .map format
+func SaveGrid(w io.Writer, g *Grid) error {
+	rows, err := g.rows()
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "type octile\nheight %d\nwidth %d\nmap\n", g.H, g.W)
+	for _, row := range rows {
+		bw.WriteString(row)
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// the JSON form of a grid: the rows of a .map along with the terrain table
+// (indexed by kind) and the start and goal, which are null if unset
+type GridJSON struct {
+	Width   int           `json:"width"`
+	Height  int           `json:"height"`
+	Terrain []TerrainType `json:"terrain"`
+	Rows    []string      `json:"rows"`
+	Start   *Position     `json:"start"`
+	Goal    *Position     `json:"goal"`
+}
+
+// reads a grid in the JSON format, returning it along with its start and
+// goal (nil if unset). If the file has no terrain table, the grid is given a
+// copy of DefaultTerrain
+func LoadGridJSON(r io.Reader) (g *Grid, start *Position, goal *Position,
+	err error) {
+	var gj GridJSON
+	if err = json.NewDecoder(r).Decode(&gj); err != nil {
+		return nil, nil, nil, err
+	}
+	if gj.Width < 1 || gj.Height < 1 {
+		return nil, nil, nil, fmt.Errorf("map: bad size %dx%d",
+			gj.Width, gj.Height)
+	}
+	g = NewGrid(gj.Width, gj.Height)
+	if gj.Terrain != nil {
+		if len(gj.Terrain) < len(kindChars) {
+			return nil, nil, nil, fmt.Errorf(
+				"map: terrain table has %d kinds, need %d",
+				len(gj.Terrain), len(kindChars))
+		}
+		// a cost below 1 would let MinCost overestimate (or make steps
+		// free or negative), and the searches would lose optimality
+		for kind, t := range gj.Terrain {
+			if t.Cost < 1 && t.Cost != IMPASSABLE {
+				return nil, nil, nil, fmt.Errorf(
+					"map terrain %d (%q): bad cost %d", kind, t.Name, t.Cost)
+			}
+		}
+		g.Terrain = gj.Terrain
+	}
+	if err = g.setRows(gj.Rows); err != nil {
+		return nil, nil, nil, err
+	}
+	for _, p := range []*Position{gj.Start, gj.Goal} {
+		if p != nil && !g.InGrid(*p) {
+			return nil, nil, nil, fmt.Errorf("map: %v is off the grid", *p)
+		}
+	}
+	return g, gj.Start, gj.Goal, nil
+}
+
+// writes the grid in the JSON format, with the given start and goal, either
+// of which may be nil
+func SaveGridJSON(w io.Writer, g *Grid,
+	start *Position, goal *Position) error {
+	rows, err := g.rows()
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(GridJSON{
+		Width:   g.W,
+		Height:  g.H,
+		Terrain: g.Terrain,
+		Rows:    rows,
+		Start:   start,
+		Goal:    goal,
+	})
+}
+
+// fills Cells from map rows, which must match the grid's size
+func (g *Grid) setRows(rows []string) error {
+	if len(rows) != g.H {
+		return fmt.Errorf("map: %d rows, want %d", len(rows), g.H)
+	}
+	for y, row := range rows {
+		if len(row) != g.W {
+			return fmt.Errorf("map row %d: %d cells, want %d",
+				y, len(row), g.W)
+		}
+		for x := 0; x < g.W; x++ {
+			kind, ok := charKinds[row[x]]
+			if !ok {
+				return fmt.Errorf("map row %d: unknown cell %q", y, row[x])
+			}
+			g.Cells[x][y] = kind
+		}
+	}
+	return nil
+}
+
+// returns Cells as map rows
+func (g *Grid) rows() ([]string, error) {
+	rows := make([]string, g.H)
+	buf := make([]byte, g.W)
+	for y := 0; y < g.H; y++ {
+		for x := 0; x < g.W; x++ {
+			c, ok := kindChars[g.Cells[x][y]]
+			if !ok {
+				return nil, fmt.Errorf("map: no character for kind %d at %v",
+					g.Cells[x][y], Position{x, y})
+			}
+			buf[x] = c
+		}
+		rows[y] = string(buf)
+	}
+	return rows, nil
+}
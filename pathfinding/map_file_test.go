@@ -0,0 +1,36 @@
+package pathfinding
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLoadGridJSONRejectsBadCosts(t *testing.T) {
+	g := NewGrid(3, 2)
+	rows, err := g.rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cost := range []int{1, 0, -2} {
+		gj := GridJSON{
+			Width:   g.W,
+			Height:  g.H,
+			Terrain: append([]TerrainType(nil), g.Terrain...),
+			Rows:    rows,
+		}
+		gj.Terrain[MUD].Cost = cost
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(gj); err != nil {
+			t.Fatal(err)
+		}
+		_, _, _, err := LoadGridJSON(&buf)
+		switch {
+		case cost >= 1 && err != nil:
+			t.Errorf("cost %d: %v", cost, err)
+		case cost < 1 && (err == nil || !strings.Contains(err.Error(), "mud")):
+			t.Errorf("cost %d: got error %v, want one naming mud", cost, err)
+		}
+	}
+}
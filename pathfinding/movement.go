@@ -0,0 +1,188 @@
+package pathfinding
+
+// a step a unit can take: the x, y offset to the neighbor and the cost of
+// the step before terrain weighting (10 per straight cell, 14 diagonal, ...)
+type Move struct {
+	DX   int
+	DY   int
+	Cost int
+}
+
+// decides which neighbors a unit may step to from a cell, and at what cost,
+// so that different kinds of units can path over the same grid differently
+type MovementModel interface {
+	// the steps the model offers out of any cell
+	Moves() []Move
+	// whether the move m out of cur is allowed, given that the cell it
+	// lands on is in the grid and passable (this is where corner rules go)
+	CanMove(g *Grid, cur Position, m Move) bool
+	// a heuristic which is admissible for the model's step costs
+	DefaultHeuristic() Heuristic
+}
+
+// how a GridMovement treats the cells a non-straight step brushes past on
+// its way (for a diagonal step, the two cells sharing its corner)
+type CornerRule int
+
+const (
+	// every cell brushed past must be passable
+	NO_CORNER_CUTTING CornerRule = iota
+	// at least one of the cells brushed past must be passable
+	CUT_ONE_CORNER = iota
+)
+
+// a MovementModel given by a table of moves and a corner rule
+type GridMovement struct {
+	Name      string
+	Steps     []Move
+	Corners   CornerRule
+	Heuristic Heuristic
+}
+
+// straight moves only
+//
+//	Y ^
+//	  |
+//	  |               0,  1
+//	  |
+//	  |    -1,  0     [cur]     1,  0
+//	  |
+//	  |               0, -1
+//	  |
+//	   --------------------------------->
+//	                                    X
+var FourConnected = &GridMovement{
+	Name: "4-connected",
+	Steps: []Move{
+		{0, 1, 10},
+		{-1, 0, 10},
+		{1, 0, 10},
+		{0, -1, 10},
+	},
+	Corners:   NO_CORNER_CUTTING,
+	Heuristic: ManhattanHeuristic{},
+}
+
+// straight and diagonal moves, where a diagonal can't squeeze past an
+// obstacle on either of its corners
+//
+//	Y ^
+//	  |
+//	  |    -1,  1     0,  1     1,  1
+//	  |
+//	  |    -1,  0     [cur]     1,  0
+//	  |
+//	  |    -1, -1     0, -1     1, -1
+//	  |
+//	   --------------------------------->
+//	                                    X
+var EightConnected = &GridMovement{
+	Name:      "8-connected",
+	Steps:     eightConnectedSteps,
+	Corners:   NO_CORNER_CUTTING,
+	Heuristic: OctileHeuristic{},
+}
+
+// straight and diagonal moves, where a diagonal may cut a corner as long as
+// only one of its two sides is blocked
+var EightConnectedCutting = &GridMovement{
+	Name:      "8-connected, corner cutting",
+	Steps:     eightConnectedSteps,
+	Corners:   CUT_ONE_CORNER,
+	Heuristic: OctileHeuristic{},
+}
+
+// straight, diagonal and knight moves (16 directions). A knight move
+// brushes past the straight and the diagonal cell between it and its
+// target, neither of which may be blocked
+var SixteenConnected = &GridMovement{
+	Name: "16-connected",
+	Steps: append([]Move{
+		// 22 ~= 10 * sqrt(5)
+		{-1, 2, 22},
+		{1, 2, 22},
+		{-2, 1, 22},
+		{2, 1, 22},
+		{-2, -1, 22},
+		{2, -1, 22},
+		{-1, -2, 22},
+		{1, -2, 22},
+	}, eightConnectedSteps...),
+	Corners: NO_CORNER_CUTTING,
+	// octile would count a knight move as 24
+	Heuristic: ChebyshevHeuristic{},
+}
+
+var eightConnectedSteps = []Move{
+	{-1, 1, 14},
+	{0, 1, 10},
+	{1, 1, 14},
+	{-1, 0, 10},
+	{1, 0, 10},
+	{-1, -1, 14},
+	{0, -1, 10},
+	{1, -1, 14},
+}
+
+func (m *GridMovement) Moves() []Move {
+	return m.Steps
+}
+
+func (m *GridMovement) DefaultHeuristic() Heuristic {
+	return m.Heuristic
+}
+
+func (m *GridMovement) CanMove(g *Grid, cur Position, mv Move) bool {
+	// straight moves brush past nothing
+	if mv.DX == 0 || mv.DY == 0 {
+		return true
+	}
+	blocked := 0
+	sides := brushedCells(cur, mv)
+	for _, p := range sides {
+		if !g.InGrid(p) || g.IsObstacle(p) {
+			blocked++
+		}
+	}
+	switch m.Corners {
+	case CUT_ONE_CORNER:
+		return blocked < len(sides)
+	default:
+		return blocked == 0
+	}
+}
+
+// the cells, other than cur and its target, which a non-straight move
+// passes alongside
+func brushedCells(cur Position, mv Move) [2]Position {
+	sx := sign(mv.DX)
+	sy := sign(mv.DY)
+	switch {
+	// knight move, long in y
+	case mv.DX*mv.DX < mv.DY*mv.DY:
+		return [2]Position{
+			{cur.X, cur.Y + sy},
+			{cur.X + sx, cur.Y + sy}}
+	// knight move, long in x
+	case mv.DX*mv.DX > mv.DY*mv.DY:
+		return [2]Position{
+			{cur.X + sx, cur.Y},
+			{cur.X + sx, cur.Y + sy}}
+	// diagonal
+	default:
+		return [2]Position{
+			{cur.X, cur.Y + sy},
+			{cur.X + sx, cur.Y}}
+	}
+}
+
+func sign(x int) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	default:
+		return 0
+	}
+}
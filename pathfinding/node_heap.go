@@ -1,4 +1,4 @@
-package main
+package pathfinding
 
 import (
 	"bytes"
@@ -99,6 +99,14 @@ func (h *NodeHeap) Pop() (*Node, error) {
 	return n, nil
 }
 
+// returns the node Pop would, without removing it
+func (h *NodeHeap) Peek() (*Node, error) {
+	if h.Len() == 0 {
+		return nil, errors.New("heap empty")
+	}
+	return h.Arr[1], nil
+}
+
 func (h *NodeHeap) Modified(n *Node) {
 	// if less than parent, bubble up
 	parentIX := n.HeapIX >> 1
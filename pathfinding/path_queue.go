@@ -0,0 +1,160 @@
+package pathfinding
+
+import (
+	"container/heap"
+	"sync"
+)
+
+// a query submitted to a PathQueue. Its result is sent on C (which has room
+// for it, so it's never blocked on) and passed to the callback, if any
+type PathTicket struct {
+	Query    PathQuery
+	Priority int
+	C        chan PathResult
+	callback func(PathResult)
+	// order of submission, to keep equal priorities first-come first-served
+	seq       int
+	cancelled bool
+	q         *PathQueue
+}
+
+// Cancel the query. If it hasn't finished yet, its result is ErrCancelled
+func (t *PathTicket) Cancel() {
+	t.q.mu.Lock()
+	defer t.q.mu.Unlock()
+	t.cancelled = true
+}
+
+// runs path queries a little at a time, so that a game loop can spread
+// searches over many frames instead of stalling on them. Queries are
+// submitted from any goroutine and run one after the other, highest Priority
+// first, by calls to Tick, each of which spends at most Budget node
+// expansions. A running search is not interrupted by a new submission, even
+// one of higher priority
+type PathQueue struct {
+	// the node expansions a single Tick may spend
+	Budget int
+	// the computer running the searches. Its Movement and Heuristic are
+	// used by queries which don't choose their own
+	Computer *AStarPathComputer
+	mu       sync.Mutex
+	pending  ticketHeap
+	active   *PathTicket
+	seq      int
+}
+
+func NewPathQueue(grid *Grid, budget int) *PathQueue {
+	return &PathQueue{
+		Budget:   budget,
+		Computer: NewAStarPathComputer(grid),
+	}
+}
+
+// queues a query. callback may be nil; if not, it is called from Tick
+func (q *PathQueue) Submit(query PathQuery, priority int,
+	callback func(PathResult)) *PathTicket {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	t := &PathTicket{
+		Query:    query,
+		Priority: priority,
+		C:        make(chan PathResult, 1),
+		callback: callback,
+		seq:      q.seq,
+		q:        q,
+	}
+	q.seq++
+	heap.Push(&q.pending, t)
+	return t
+}
+
+// the number of queries not yet finished
+func (q *PathQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := q.pending.Len()
+	if q.active != nil {
+		n++
+	}
+	return n
+}
+
+// runs queued searches for up to Budget node expansions, delivering the
+// results of any that finish. Call it from one goroutine only (typically
+// once per frame); callbacks run on that goroutine
+func (q *PathQueue) Tick() {
+	budget := q.Budget
+	for budget > 0 {
+		t, start := q.next()
+		if t == nil {
+			return
+		}
+		if start {
+			q.Computer.Begin(t.Query.Start, t.Query.End, t.Query.Options)
+		}
+		before := q.Computer.Result().Expanded
+		done := q.Computer.Step(budget)
+		budget -= q.Computer.Result().Expanded - before
+		if done {
+			q.finish(t, q.Computer.Result())
+		}
+	}
+}
+
+// returns the ticket to work on: the active one, or else the next pending
+// one (start == true), which becomes active. Cancelled tickets are finished
+// along the way
+func (q *PathQueue) next() (t *PathTicket, start bool) {
+	for {
+		q.mu.Lock()
+		t = q.active
+		start = false
+		if t == nil && q.pending.Len() > 0 {
+			t = heap.Pop(&q.pending).(*PathTicket)
+			q.active = t
+			start = true
+		}
+		cancelled := t != nil && t.cancelled
+		q.mu.Unlock()
+		if !cancelled {
+			return t, start
+		}
+		q.finish(t, PathResult{Err: ErrCancelled})
+	}
+}
+
+// delivers a ticket's result and clears it from being active
+func (q *PathQueue) finish(t *PathTicket, res PathResult) {
+	q.mu.Lock()
+	if q.active == t {
+		q.active = nil
+	}
+	q.mu.Unlock()
+	t.C <- res
+	if t.callback != nil {
+		t.callback(res)
+	}
+}
+
+// pending tickets, highest priority first, then first submitted first
+type ticketHeap []*PathTicket
+
+func (h ticketHeap) Len() int { return len(h) }
+
+func (h ticketHeap) Less(i int, j int) bool {
+	if h[i].Priority != h[j].Priority {
+		return h[i].Priority > h[j].Priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h ticketHeap) Swap(i int, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *ticketHeap) Push(x interface{}) { *h = append(*h, x.(*PathTicket)) }
+
+func (h *ticketHeap) Pop() interface{} {
+	old := *h
+	t := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return t
+}
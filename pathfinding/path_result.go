@@ -0,0 +1,67 @@
+package pathfinding
+
+import (
+	"errors"
+	"time"
+)
+
+// errors reported in PathResult.Err
+var (
+	ErrNoPath       = errors.New("no path between start and end")
+	ErrStartBlocked = errors.New("start is on impassable terrain")
+	ErrEndBlocked   = errors.New("end is on impassable terrain")
+	ErrOutOfBounds  = errors.New("start or end is outside the grid")
+	ErrCancelled    = errors.New("path query was cancelled")
+	ErrNoBounds     = errors.New("solver can't keep to search bounds")
+)
+
+// the outcome of a path query
+type PathResult struct {
+	// the path, ordered from start to end (both included). Empty if Err
+	// is set
+	Path []Position
+	// total cost of the path (sum of terrain-weighted step costs)
+	Cost int
+	// for any-angle searches, the centers of the cells of Path in world
+	// space, and the world-space length of the line through them
+	Waypoints []Vec2D
+	Length    float64
+	// number of nodes popped from the open heap and expanded
+	Expanded int
+	// number of nodes pushed to the open heap
+	Generated int
+	// time taken by the search
+	Elapsed time.Duration
+	// nil on success, otherwise one of the Err* values above
+	Err error
+}
+
+// follows the From pointers back from the end node to build the path
+// ordered from start to end
+func tracePath(end *Node) []Position {
+	n := 0
+	for cur := end; cur != nil; cur = cur.From {
+		n++
+	}
+	path := make([]Position, n)
+	for cur := end; cur != nil; cur = cur.From {
+		n--
+		path[n] = cur.Pos
+	}
+	return path
+}
+
+// checks the start and end of a query against the grid, returning one of
+// ErrOutOfBounds, ErrStartBlocked, ErrEndBlocked or nil
+func checkEndpoints(g *Grid, start Position, end Position) error {
+	if !g.InGrid(start) || !g.InGrid(end) {
+		return ErrOutOfBounds
+	}
+	if g.IsObstacle(start) {
+		return ErrStartBlocked
+	}
+	if g.IsObstacle(end) {
+		return ErrEndBlocked
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package pathfinding
+
+import (
+	"runtime"
+	"sync"
+)
+
+// a single path query: where from, where to, and how
+type PathQuery struct {
+	Start   Position
+	End     Position
+	Options SearchOptions
+}
+
+// answers path queries from any number of goroutines at once over one shared
+// Grid. Each query borrows an AStarPathComputer from a pool, so no two
+// queries share search state, and the grid is only read while queries run.
+// Changes to the grid must go through Update, which waits for running
+// queries and holds off new ones while it runs
+type PathService struct {
+	grid *Grid
+	// held for reading by queries and for writing by Update
+	mu   sync.RWMutex
+	pool sync.Pool
+}
+
+func NewPathService(grid *Grid) *PathService {
+	s := &PathService{grid: grid}
+	s.pool.New = func() interface{} {
+		return NewAStarPathComputer(grid)
+	}
+	return s
+}
+
+// searches for a path, safe to call from any goroutine
+func (s *PathService) FindPath(
+	start Position, end Position, opts SearchOptions) PathResult {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c := s.pool.Get().(*AStarPathComputer)
+	defer s.pool.Put(c)
+	return c.AStarPathWithOptions(start, end, opts)
+}
+
+// answers a batch of queries in parallel, one worker per CPU, returning the
+// results in the same order as the queries
+func (s *PathService) FindPaths(queries []PathQuery) []PathResult {
+	results := make([]PathResult, len(queries))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				q := queries[i]
+				results[i] = s.FindPath(q.Start, q.End, q.Options)
+			}
+		}()
+	}
+	for i := range queries {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+// runs f with exclusive access to the grid, so it can change Cells or
+// Terrain. f must not change the grid's dimensions
+func (s *PathService) Update(f func(g *Grid)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(s.grid)
+}
@@ -0,0 +1,94 @@
+package pathfinding
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// copies the cells of a grid
+func copyCells(cells [][]int) [][]int {
+	out := make([][]int, len(cells))
+	for x := range cells {
+		out[x] = append([]int(nil), cells[x]...)
+	}
+	return out
+}
+
+// FindPaths runs from several goroutines while Update switches the grid
+// between two layouts. Each query holds the grid for its whole search, so
+// its result must be the one a lone computer finds on one of the layouts
+func TestPathServiceConcurrent(t *testing.T) {
+	const w, h = 40, 40
+	rng := rand.New(rand.NewSource(1))
+	layouts := [2][][]int{
+		(&NoiseGenerator{Density: 0.2}).Generate(w, h, rng),
+		(&NoiseGenerator{Density: 0.3}).Generate(w, h, rng),
+	}
+	grid := NewGrid(w, h)
+	grid.Cells = copyCells(layouts[0])
+
+	var queries []PathQuery
+	for i := 0; i < 50; i++ {
+		queries = append(queries, PathQuery{
+			Start: Position{rng.Intn(w), rng.Intn(h)},
+			End:   Position{rng.Intn(w), rng.Intn(h)},
+		})
+	}
+	// the results expected on each layout
+	var want [2][]PathResult
+	for l, cells := range layouts {
+		g := NewGrid(w, h)
+		g.Cells = copyCells(cells)
+		c := NewAStarPathComputer(g)
+		for _, q := range queries {
+			want[l] = append(want[l], c.AStarPath(q.Start, q.End))
+		}
+	}
+
+	s := NewPathService(grid)
+	stop := make(chan struct{})
+	updated := make(chan struct{})
+	go func() {
+		defer close(updated)
+		for l := 1; ; l = 1 - l {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			s.Update(func(g *Grid) {
+				for x := 0; x < w; x++ {
+					copy(g.Cells[x], layouts[l][x])
+				}
+			})
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for round := 0; round < 5; round++ {
+				for i, res := range s.FindPaths(queries) {
+					if !sameResult(res, want[0][i]) &&
+						!sameResult(res, want[1][i]) {
+						t.Errorf("query %d: got cost %d, err %v; want "+
+							"cost %d, err %v or cost %d, err %v",
+							i, res.Cost, res.Err,
+							want[0][i].Cost, want[0][i].Err,
+							want[1][i].Cost, want[1][i].Err)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	<-updated
+}
+
+func sameResult(a PathResult, b PathResult) bool {
+	return a.Err == b.Err && a.Cost == b.Cost && len(a.Path) == len(b.Path)
+}
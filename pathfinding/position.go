@@ -0,0 +1,15 @@
+// simple struct used to keep track of x, y positions in the grid
+package pathfinding
+
+import (
+	"fmt"
+)
+
+type Position struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("[%d, %d]", p.X, p.Y)
+}
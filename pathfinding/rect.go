@@ -0,0 +1,13 @@
+package pathfinding
+
+// a rectangle of cells: W x H cells with X, Y the lowest corner
+type Rect struct {
+	X, Y int
+	W, H int
+}
+
+// tests if a position lies in the rectangle
+func (r Rect) Contains(p Position) bool {
+	return p.X >= r.X && p.X < r.X+r.W &&
+		p.Y >= r.Y && p.Y < r.Y+r.H
+}
@@ -0,0 +1,140 @@
+package pathfinding
+
+// curves SmoothPath can fit through the cells left by string pulling
+const (
+	// straight lines between them
+	CURVE_NONE = iota
+	// a Catmull-Rom spline through them
+	CURVE_CATMULL_ROM = iota
+	// each corner rounded off by a quadratic Bézier curve running between
+	// the midpoints of the lines either side of it
+	CURVE_BEZIER = iota
+)
+
+// the number of straight pieces each curved span is drawn with
+const SMOOTH_SAMPLES = 8
+
+// removes the cells of path which the path can skip, keeping each cell only
+// if the one after it can't be seen from the last cell kept (a string
+// pulled tight along the path). Only obstacles are seen; the lines kept can
+// cross terrain the path went around because it costs more
+func StringPull(g *Grid, path []Position) []Position {
+	if len(path) < 3 {
+		return append([]Position(nil), path...)
+	}
+	pulled := []Position{path[0]}
+	anchor := path[0]
+	for i := 1; i < len(path)-1; i++ {
+		if !g.LineOfSight(anchor, path[i+1]) {
+			anchor = path[i]
+			pulled = append(pulled, anchor)
+		}
+	}
+	return append(pulled, path[len(path)-1])
+}
+
+// string pulls path and fits a curve (one of the CURVE_* kinds) through the
+// cells left, returning a world-space polyline from the center of the
+// first cell to the center of the last. The polyline never crosses an
+// obstacle: where a span of the curve would, the straight line it rounds
+// off is kept instead
+func SmoothPath(g *Grid, path []Position, curve int) []Vec2D {
+	pulled := StringPull(g, path)
+	pts := make([]Vec2D, len(pulled))
+	for i, p := range pulled {
+		pts[i] = g.GridCellSpaceToGridWorldSpace(p)
+	}
+	if len(pts) < 3 {
+		return pts
+	}
+	switch curve {
+	case CURVE_CATMULL_ROM:
+		return catmullRom(g, pts)
+	case CURVE_BEZIER:
+		return roundCorners(g, pts)
+	}
+	return pts
+}
+
+// a Catmull-Rom spline through pts, the ends repeated to give the first and
+// last spans their outer control points
+func catmullRom(g *Grid, pts []Vec2D) []Vec2D {
+	n := len(pts)
+	out := []Vec2D{pts[0]}
+	for i := 0; i < n-1; i++ {
+		p1 := pts[i]
+		p2 := pts[i+1]
+		p0, p3 := p1, p2
+		if i > 0 {
+			p0 = pts[i-1]
+		}
+		if i+2 < n {
+			p3 = pts[i+2]
+		}
+		span := make([]Vec2D, SMOOTH_SAMPLES)
+		for k := 1; k <= SMOOTH_SAMPLES; k++ {
+			span[k-1] = catmullRomPoint(p0, p1, p2, p3,
+				float64(k)/SMOOTH_SAMPLES)
+		}
+		out = appendSpan(g, out, span, []Vec2D{p2})
+	}
+	return out
+}
+
+// the point at t of the Catmull-Rom span from p1 (t = 0) to p2 (t = 1)
+func catmullRomPoint(p0 Vec2D, p1 Vec2D, p2 Vec2D, p3 Vec2D, t float64) Vec2D {
+	t2 := t * t
+	t3 := t2 * t
+	return p1.Scale(2).
+		Add(p2.Sub(p0).Scale(t)).
+		Add(p0.Scale(2).Sub(p1.Scale(5)).
+			Add(p2.Scale(4)).Sub(p3).Scale(t2)).
+		Add(p1.Scale(3).Sub(p0).Sub(p2.Scale(3)).
+			Add(p3).Scale(t3)).
+		Scale(0.5)
+}
+
+// pts with each inner corner replaced by a quadratic Bézier curve from the
+// midpoint of the line before it to the midpoint of the line after it,
+// with the corner as its control point
+func roundCorners(g *Grid, pts []Vec2D) []Vec2D {
+	n := len(pts)
+	out := []Vec2D{pts[0]}
+	for i := 1; i < n-1; i++ {
+		a := pts[i-1].Add(pts[i]).Scale(0.5)
+		c := pts[i]
+		b := pts[i].Add(pts[i+1]).Scale(0.5)
+		out = append(out, a)
+		span := make([]Vec2D, SMOOTH_SAMPLES)
+		for k := 1; k <= SMOOTH_SAMPLES; k++ {
+			t := float64(k) / SMOOTH_SAMPLES
+			u := 1 - t
+			span[k-1] = a.Scale(u * u).
+				Add(c.Scale(2 * u * t)).
+				Add(b.Scale(t * t))
+		}
+		out = appendSpan(g, out, span, []Vec2D{c, b})
+	}
+	return append(out, pts[n-1])
+}
+
+// appends span, the points of a curve continuing from the last point of
+// out, if none of its pieces crosses an obstacle, or else fallback
+func appendSpan(g *Grid, out []Vec2D, span []Vec2D, fallback []Vec2D) []Vec2D {
+	from := out[len(out)-1]
+	for _, to := range span {
+		if !segmentClear(g, from, to) {
+			return append(out, fallback...)
+		}
+		from = to
+	}
+	return append(out, span...)
+}
+
+// tests if the world-space segment from a to b crosses only passable
+// cells, not squeezing between obstacles which meet at a corner
+func segmentClear(g *Grid, a Vec2D, b Vec2D) bool {
+	d := b.Sub(a)
+	_, _, blocked := g.RayCast(a, d, d.Magnitude())
+	return !blocked
+}
@@ -0,0 +1,90 @@
+package pathfinding
+
+import (
+	"testing"
+)
+
+// a grid with a wall along x = 5 from y = 0 to y = 7, which paths from the
+// left of it to the right must bend around
+func bentGrid() *Grid {
+	g := NewGrid(12, 12)
+	g.WorldW = 120
+	g.WorldH = 120
+	for y := 0; y < 8; y++ {
+		g.Cells[5][y] = OBSTACLE
+	}
+	return g
+}
+
+func near(a Vec2D, b Vec2D) bool {
+	return a.Sub(b).Magnitude() < 1e-9
+}
+
+func TestCatmullRomSpanEnds(t *testing.T) {
+	p0 := Vec2D{15, 15}
+	p1 := Vec2D{45, 85}
+	p2 := Vec2D{65, 85}
+	p3 := Vec2D{105, 15}
+	if got := catmullRomPoint(p0, p1, p2, p3, 0); !near(got, p1) {
+		t.Errorf("span starts at %v, want %v", got, p1)
+	}
+	if got := catmullRomPoint(p0, p1, p2, p3, 1); !near(got, p2) {
+		t.Errorf("span ends at %v, want %v", got, p2)
+	}
+}
+
+func TestSmoothPathBent(t *testing.T) {
+	g := bentGrid()
+	start := Position{1, 1}
+	end := Position{10, 1}
+	res := NewAStarPathComputer(g).AStarPath(start, end)
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	pulled := StringPull(g, res.Path)
+	if len(pulled) < 3 {
+		t.Fatalf("string pulled path %v doesn't bend", pulled)
+	}
+	for _, curve := range []int{CURVE_NONE, CURVE_CATMULL_ROM, CURVE_BEZIER} {
+		pts := SmoothPath(g, res.Path, curve)
+		first := g.GridCellSpaceToGridWorldSpace(start)
+		last := g.GridCellSpaceToGridWorldSpace(end)
+		if !near(pts[0], first) || !near(pts[len(pts)-1], last) {
+			t.Errorf("curve %d runs from %v to %v, want %v to %v",
+				curve, pts[0], pts[len(pts)-1], first, last)
+		}
+		for i := 1; i < len(pts); i++ {
+			if !segmentClear(g, pts[i-1], pts[i]) {
+				t.Errorf("curve %d: %v to %v crosses an obstacle",
+					curve, pts[i-1], pts[i])
+			}
+		}
+	}
+	// each Catmull-Rom span through the bend runs from one pulled cell to
+	// the next, and stays on passable cells
+	pts := make([]Vec2D, len(pulled))
+	for i, p := range pulled {
+		pts[i] = g.GridCellSpaceToGridWorldSpace(p)
+	}
+	for i := 0; i+1 < len(pts); i++ {
+		p0, p3 := pts[i], pts[i+1]
+		if i > 0 {
+			p0 = pts[i-1]
+		}
+		if i+2 < len(pts) {
+			p3 = pts[i+2]
+		}
+		prev := pts[i]
+		for k := 1; k <= SMOOTH_SAMPLES; k++ {
+			p := catmullRomPoint(p0, pts[i], pts[i+1], p3,
+				float64(k)/SMOOTH_SAMPLES)
+			if g.blocked(g.GridWorldSpaceToGridCellSpace(p)) {
+				t.Errorf("span %d passes through obstacle at %v", i, p)
+			}
+			prev = p
+		}
+		if !near(prev, pts[i+1]) {
+			t.Errorf("span %d ends at %v, want %v", i, prev, pts[i+1])
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package pathfinding
+
+import (
+	"math/rand"
+)
+
+// terrain kinds, as stored in Grid.Cells. START and END are plain ground
+// kept for grids which still mark their endpoints in the cells; endpoints
+// are better kept apart, so the terrain under them isn't lost
+const (
+	EMPTY    = 0
+	OBSTACLE = iota
+	START    = iota
+	END      = iota
+	ROAD     = iota
+	FOREST   = iota
+	MUD      = iota
+	WATER    = iota
+	TREES    = iota
+)
+
+// the Cost of a terrain type which can't be entered at all
+const IMPASSABLE = -1
+
+// describes a kind of terrain. Cost multiplies the 10 (straight) or 14
+// (diagonal) cost of a step into a cell of this kind, or is IMPASSABLE
+type TerrainType struct {
+	Name string `json:"name"`
+	Cost int    `json:"cost"`
+}
+
+// the terrain table given to new grids, indexed by terrain kind. Empty
+// ground costs as little as roads, the cheapest terrain, and everything
+// else more: heuristics are scaled by the cheapest cost in the table, so
+// anything cheaper than the ground most of a grid is made of would weaken
+// them everywhere
+var DefaultTerrain = []TerrainType{
+	EMPTY:    {"empty", 1},
+	OBSTACLE: {"obstacle", IMPASSABLE},
+	START:    {"start", 1},
+	END:      {"end", 1},
+	ROAD:     {"road", 1},
+	FOREST:   {"forest", 2},
+	MUD:      {"mud", 3},
+	WATER:    {"water", 4},
+	TREES:    {"trees", IMPASSABLE},
+}
+
+// a terrain table with the same kinds as DefaultTerrain, all passable ones
+// costing 1, for the uniform-cost grids which JPS and most benchmark maps
+// assume. With no cheaper terrain around, heuristics are as tight as they
+// can be
+var UniformTerrain = []TerrainType{
+	EMPTY:    {"empty", 1},
+	OBSTACLE: {"obstacle", IMPASSABLE},
+	START:    {"start", 1},
+	END:      {"end", 1},
+	ROAD:     {"road", 1},
+	FOREST:   {"forest", 1},
+	MUD:      {"mud", 1},
+	WATER:    {"water", 1},
+	TREES:    {"trees", IMPASSABLE},
+}
+
+// generates random terrain of grid cells, seeding obstacle blobs with the
+// given density, drawing from the global random source. For terrain that
+// can be reproduced, use a TerrainGenerator with a seed
+func MakeTerrain(w int, h int, density float64) [][]int {
+	gen := &BlobGenerator{Density: density}
+	return gen.Generate(w, h, rand.New(rand.NewSource(rand.Int63())))
+}
@@ -0,0 +1,159 @@
+package pathfinding
+
+import (
+	"math"
+	"time"
+)
+
+// Theta* searches measure G and H in thousandths of a cell of straight-line
+// distance, so that rounding stays well below a step's cost
+const THETA_SCALE = 1000
+
+// Theta*: an any-angle A*. A neighbor which can see the parent of the node
+// being expanded is linked straight to that parent, so paths run in straight
+// lines between the corners they turn at rather than zig-zagging along the 8
+// grid directions. Neighbors are those of EightConnected movement, and like
+// JPS it assumes uniform terrain, finding the path shortest in straight-line
+// length whatever it crosses. Its Cost, though, weighs each stretch of the
+// path by the cost of the cells it crosses, 10 per cell, so that it can be
+// compared with AStarPath's. Path holds the cells turned at, and Waypoints
+// their centers in world space
+func (c *AStarPathComputer) ThetaStarPath(
+	start Position, end Position) PathResult {
+	return c.thetaStar(start, end, false)
+}
+
+// Lazy Theta*: Theta*, but linking each neighbor to the parent without
+// checking line of sight, and checking it only once the neighbor is
+// expanded, when a failed check is repaired by relinking to the best closed
+// neighbor. Far fewer line of sight checks are made, for paths which are
+// at most slightly longer
+func (c *AStarPathComputer) LazyThetaStarPath(
+	start Position, end Position) PathResult {
+	return c.thetaStar(start, end, true)
+}
+
+func (c *AStarPathComputer) thetaStar(
+	start Position, end Position, lazy bool) (res PathResult) {
+	t0 := time.Now()
+	defer func() { res.Elapsed = time.Since(t0) }()
+	if res.Err = checkEndpoints(c.Grid, start, end); res.Err != nil {
+		return res
+	}
+	movement := EightConnected
+
+	c.begin(start, end, euclidean(start, end))
+	res.Generated++
+
+	for c.OH.Len() > 0 {
+		cur, err := c.OH.Pop()
+		if err != nil {
+			break
+		}
+		if lazy && cur.From != nil &&
+			!c.Grid.LineOfSight(cur.From.Pos, cur.Pos) {
+			c.relinkToClosed(cur, movement)
+		}
+		res.Expanded++
+		cur.WhichList = c.N + 1
+		cur.Order = res.Expanded
+		if cur == c.endNode {
+			res.Path = tracePath(cur)
+			res.Waypoints = make([]Vec2D, len(res.Path))
+			cost := 0.0
+			for i, p := range res.Path {
+				res.Waypoints[i] = c.Grid.GridCellSpaceToGridWorldSpace(p)
+				if i > 0 {
+					res.Length += res.Waypoints[i].Sub(
+						res.Waypoints[i-1]).Magnitude()
+					cost += c.Grid.segmentCost(res.Path[i-1], p)
+				}
+			}
+			res.Cost = int(math.Round(cost))
+			return res
+		}
+		for _, m := range movement.Moves() {
+			nbrPos, _, err := c.Grid.NbrOf(cur.Pos, m, movement)
+			if err != nil {
+				continue
+			}
+			nbr := &c.Nodes[nbrPos.X][nbrPos.Y]
+			// unlike AStarPath, never reopen a closed node: a lazy link
+			// can promise it a G lower than any it can really have
+			if nbr.WhichList == c.N+1 {
+				continue
+			}
+			// link the neighbor to cur's parent if it can see it (or,
+			// lazily, without looking), else to cur
+			from := cur
+			if cur.From != nil &&
+				(lazy || c.Grid.LineOfSight(cur.From.Pos, nbrPos)) {
+				from = cur.From
+			}
+			g := from.G + euclidean(from.Pos, nbrPos)
+			h := euclidean(nbrPos, end)
+			if c.consider(c.OH, from, nbr, g, h) {
+				res.Generated++
+			}
+		}
+	}
+	res.Err = ErrNoPath
+	return res
+}
+
+// for Lazy Theta*: links n, which can't see the parent it was given, to
+// whichever closed neighbor gives it the lowest G instead. The neighbor
+// which generated n is among them, so there's always one
+func (c *AStarPathComputer) relinkToClosed(n *Node, movement MovementModel) {
+	best := -1
+	for _, m := range movement.Moves() {
+		p, _, err := c.Grid.NbrOf(n.Pos, m, movement)
+		if err != nil {
+			continue
+		}
+		nbr := &c.Nodes[p.X][p.Y]
+		if nbr.WhichList != c.N+1 {
+			continue
+		}
+		if g := nbr.G + euclidean(p, n.Pos); best < 0 || g < best {
+			best = g
+			n.From = nbr
+		}
+	}
+	if best >= 0 {
+		n.G = best
+		n.F = n.G + n.H
+	}
+}
+
+// the cost of the segment between the centers of cells a and b: the length
+// of it in each cell it passes through, times the cell's cost, summed, at
+// 10 per cell
+func (g *Grid) segmentCost(a Position, b Position) float64 {
+	dx, dy := absDeltas(a, b)
+	cost := 0.0
+	// the fraction of the segment walked so far
+	t0 := 0.0
+	for it := NewSupercover(a, b); it.Next(); {
+		// the segment leaves the cell at the next boundary it crosses, or
+		// ends in it
+		t := 1.0
+		if it.ix < it.dx {
+			t = float64(2*it.ix+1) / float64(2*it.dx)
+		}
+		if it.iy < it.dy {
+			t = math.Min(t, float64(2*it.iy+1)/float64(2*it.dy))
+		}
+		cost += (t - t0) * float64(g.CostOf(it.Pos))
+		t0 = t
+	}
+	return 10 * math.Sqrt(float64(dx*dx+dy*dy)) * cost
+}
+
+// the straight-line distance between the centers of two cells, in units
+// of THETA_SCALE per cell
+func euclidean(a Position, b Position) int {
+	dx, dy := absDeltas(a, b)
+	return int(math.Round(
+		THETA_SCALE * math.Sqrt(float64(dx*dx+dy*dy))))
+}
@@ -1,5 +1,5 @@
 // simple 2d geometry class
-package main
+package pathfinding
 
 import (
 	"math"
@@ -1,20 +1,11 @@
-// simple struct used to keep track of x, y positions in the grid
+// pairs of grid positions, used to draw path segments
 package main
 
 import (
-	"fmt"
+	"github.com/dt-rush/go-sdl2-pathfinding/pathfinding"
 )
 
-type Position struct {
-	X int
-	Y int
-}
-
-func (p Position) String() string {
-	return fmt.Sprintf("[%d, %d]", p.X, p.Y)
-}
-
 type PositionPair struct {
-	p1 Position
-	p2 Position
+	p1 pathfinding.Position
+	p2 pathfinding.Position
 }
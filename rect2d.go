@@ -1,6 +1,7 @@
 package main
 
 import (
+	"github.com/dt-rush/go-sdl2-pathfinding/pathfinding"
 	"github.com/veandco/go-sdl2/sdl"
 )
 
@@ -9,20 +10,31 @@ type Rect2D struct {
 	W, H float64
 }
 
-func CenteredSquare(p Vec2D, r float64) Rect2D {
+func CenteredSquare(p pathfinding.Vec2D, r float64) Rect2D {
 	return Rect2D{p.X - r/2, p.Y - r/2, r, r}
 }
 
-func (r Rect2D) ToScreenSpaceSdlRect() sdl.Rect {
-	// set the corner to top-left instead of bottom-left
-	r.Y += r.H
-	x, y := GridWorldSpaceToScreenSpace(Vec2D{r.X, r.Y})
-	w := WINDOW_WIDTH * (r.W / float64(GRID_WORLD_DIMENSION))
-	h := WINDOW_WIDTH * (r.H / float64(GRID_WORLD_DIMENSION))
-	return sdl.Rect{int32(x), int32(y), int32(w), int32(h)}
+func (r Rect2D) ToScreenSpaceSdlRect(g *Grid) sdl.Rect {
+	// screen-space top-left and bottom-right corners (world-space y points
+	// up, so the top-left is at the world-space bottom-left plus H)
+	x0, y0 := g.GridWorldSpaceToScreenSpace(
+		pathfinding.Vec2D{X: r.X, Y: r.Y + r.H})
+	x1, y1 := g.GridWorldSpaceToScreenSpace(
+		pathfinding.Vec2D{X: r.X + r.W, Y: r.Y})
+	// never let a rect shrink to nothing, so that cells of grids with more
+	// cells than the window has pixels are still drawn
+	w := x1 - x0
+	if w < 1 {
+		w = 1
+	}
+	h := y1 - y0
+	if h < 1 {
+		h = 1
+	}
+	return sdl.Rect{X: int32(x0), Y: int32(y0), W: int32(w), H: int32(h)}
 }
 
-func (r Rect2D) Contains(p Vec2D) bool {
+func (r Rect2D) Contains(p pathfinding.Vec2D) bool {
 	return r.X <= p.X && p.X <= (r.X+r.W) && r.Y <= p.Y && p.Y <= (r.Y+r.H)
 }
 
@@ -33,6 +45,6 @@ func (r1 Rect2D) Overlaps(r2 Rect2D) bool {
 		r1.Y+r1.H < r2.Y)
 }
 
-func (r Rect2D) Add(v Vec2D) Rect2D {
+func (r Rect2D) Add(v pathfinding.Vec2D) Rect2D {
 	return Rect2D{r.X + v.X, r.Y + v.Y, r.W, r.H}
 }
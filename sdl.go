@@ -25,7 +25,7 @@ func GetRenderer() (*sdl.Renderer, int) {
 	}
 	renderer, err = sdl.CreateRenderer(window, -1, sdl.RENDERER_SOFTWARE)
 	if err != nil {
-		fmt.Fprint(os.Stderr, "Failed to create renderer: %s\n", err)
+		fmt.Fprintf(os.Stderr, "Failed to create renderer: %s\n", err)
 		return nil, 2
 	}
 	renderer.Clear()